@@ -0,0 +1,142 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"log"
+	"net/url"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"website/src"
+	"website/src/models"
+	p_ "website/src/parser"
+)
+
+// linkGraph holds the links between the pages under public/, to list the pages linking to a page.
+// It is built again when a Markdown file is added, removed or changed, and when a page is published or expires.
+type linkGraph struct {
+	mu        sync.Mutex
+	stamp     string
+	expires   time.Time                    // Next scheduled change to the published pages, zero if there is none
+	backlinks map[string][]models.Backlink // Pages linking to a page, by the path of the page
+}
+
+var links = &linkGraph{}
+
+// Backlinks returns the other pages linking to page, by their titles.
+// Stamp is the current content stamp of public/, or the empty string if it could not be found.
+func (g *linkGraph) Backlinks(page string, stamp string) []models.Backlink {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	now := time.Now()
+	if g.backlinks == nil || stamp == "" || stamp != g.stamp || (!g.expires.IsZero() && !now.Before(g.expires)) {
+		backlinks, err := buildLinkGraph()
+		if err != nil {
+			log.Println("Error building link graph:", err)
+			return nil
+		}
+		next, _, err := nextScheduledChange(now)
+		if err != nil {
+			log.Println("Error finding scheduled changes:", err)
+		}
+		g.backlinks, g.stamp, g.expires = backlinks, stamp, next.At
+	}
+	return g.backlinks[page]
+}
+
+// contentStamp identifies the state of the Markdown files under root by their names, sizes and modification times
+func contentStamp(root string) (string, error) {
+	hash := sha256.New()
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || !strings.HasSuffix(path, ".md") {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(hash, "%s %d %d\n", path, info.Size(), info.ModTime().UnixNano())
+		return nil
+	})
+	return hex.EncodeToString(hash.Sum(nil)), err
+}
+
+// buildLinkGraph finds the links in every page and resolves them to the pages they link to
+func buildLinkGraph() (map[string][]models.Backlink, error) {
+	// Every page is read once, which leaves its frontmatter for siteTree and the page index
+	all, err := models.FileTree("public", "")
+	if err != nil {
+		return nil, err
+	}
+	pages := make(map[string]src.Document)
+	for _, file := range all.AllFiles() {
+		if pages[file.Path], err = loadPage(file); err != nil {
+			return nil, err
+		}
+	}
+
+	folder, err := siteTree("")
+	if err != nil {
+		return nil, err
+	}
+	index := models.NewPageIndex(folder, pageTitle)
+
+	backlinks := make(map[string][]models.Backlink)
+	for _, file := range folder.AllFiles() {
+		page := pages[file.Path]
+		title := page.Frontmatter.Title
+		if title == "" {
+			title = file.Name
+		}
+
+		doc := newParser().Parse([]byte(page.Body))
+		linked := make(map[string]bool)
+		for _, link := range p_.PageLinks(doc) {
+			target, ok := linkedPage(index, file, link)
+			if !ok || target == file.Path || linked[target] {
+				continue
+			}
+			linked[target] = true
+			backlinks[target] = append(backlinks[target], models.Backlink{Path: file.Path, Title: title, Context: link.Context})
+		}
+	}
+
+	for _, pages := range backlinks {
+		slices.SortFunc(pages, func(a, b models.Backlink) int {
+			return strings.Compare(strings.ToLower(a.Title), strings.ToLower(b.Title))
+		})
+	}
+	return backlinks, nil
+}
+
+// linkedPage returns the path of the page a link in file goes to, if it goes to a page of the site
+func linkedPage(index *models.PageIndex, file models.File, link p_.PageLink) (string, bool) {
+	if link.Destination == "" {
+		page, err := index.Resolve(link.Target)
+		return page, err == nil
+	}
+
+	u, err := url.Parse(link.Destination)
+	if err != nil || u.Scheme != "" || u.Host != "" || u.Path == "" {
+		return "", false
+	}
+
+	// Relative links are relative to the page's folder, like they are in the browser
+	target := u.Path
+	if page, ok := strings.CutPrefix(target, "/page/"); ok {
+		target = page
+	} else if strings.HasPrefix(target, "/") {
+		return "", false
+	} else {
+		target = path.Join(path.Dir(filepath.ToSlash(file.Path)), target)
+	}
+
+	return index.ByPath(strings.TrimSuffix(target, ".md"))
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// buildSite renders every route of the site into outDir, so it can be deployed to a plain static host.
+// Pages are rendered by sending requests through the router itself, which keeps the output
+// byte-identical to what the server sends. Each route is written as <route>.html, which static hosts
+// serve at the route itself, so relative links in pages resolve against the same URL as on the server.
+func buildSite(router http.Handler, outDir string) error {
+	if err := checkOutDir(outDir); err != nil {
+		return err
+	}
+	if err := os.RemoveAll(outDir); err != nil {
+		return err
+	}
+
+	folder, err := siteTree("")
+	if err != nil {
+		return err
+	}
+
+	routes := []string{"/", "/articles"}
+	for _, file := range folder.AllFiles() {
+		routes = append(routes, "/page/"+filepath.ToSlash(file.Path))
+	}
+	for _, taxonomy := range taxonomies {
+		index := taxonomy.index(folder)
+		routes = append(routes, "/"+index.Name)
+		for _, term := range index.Terms {
+			routes = append(routes, index.URL(term))
+		}
+	}
+
+	// Pages render independently of each other, so routes are built in parallel
+	errs := make(chan error, len(routes))
+	limit := make(chan struct{}, runtime.GOMAXPROCS(0))
+	var wg sync.WaitGroup
+	for _, route := range routes {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			limit <- struct{}{}
+			defer func() { <-limit }()
+			errs <- buildRoute(router, outDir, route)
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := copyDir("static", filepath.Join(outDir, "static")); err != nil {
+		return err
+	}
+	return buildCodeStyles(outDir)
+}
+
+// checkOutDir refuses output directories that building would delete the site's own files with:
+// the working directory or one of its parents, and the content and static directories
+func checkOutDir(outDir string) error {
+	out, err := filepath.Abs(outDir)
+	if err != nil {
+		return err
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+
+	if within(wd, out) {
+		return fmt.Errorf("refusing to build into %s, it contains the working directory", outDir)
+	}
+	for _, dir := range []string{"public", "static"} {
+		if within(out, filepath.Join(wd, dir)) {
+			return fmt.Errorf("refusing to build into %s, it is inside %s/", outDir, dir)
+		}
+	}
+	return nil
+}
+
+// within reports whether path is dir or inside it
+func within(path string, dir string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+func buildRoute(router http.Handler, outDir string, route string) error {
+	req := httptest.NewRequest(http.MethodGet, route, nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		return fmt.Errorf("building %s: status %d", route, rec.Code)
+	}
+
+	path := filepath.Join(outDir, "index.html")
+	if route != "/" {
+		path = filepath.Join(outDir, filepath.FromSlash(strings.TrimPrefix(route, "/"))+".html")
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, rec.Body.Bytes(), 0644)
+}
+
+func copyDir(srcDir string, dstDir string) error {
+	return filepath.WalkDir(srcDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(srcDir, path)
+		if err != nil {
+			return err
+		}
+		dst := filepath.Join(dstDir, rel)
+
+		if d.IsDir() {
+			return os.MkdirAll(dst, 0755)
+		}
+
+		in, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer in.Close()
+
+		out, err := os.Create(dst)
+		if err != nil {
+			return err
+		}
+		defer out.Close()
+
+		_, err = io.Copy(out, in)
+		return err
+	})
+}
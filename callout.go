@@ -0,0 +1,106 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"io"
+
+	p_ "website/src/parser"
+)
+
+// calloutStyle is the daisyUI alert class and the icon of a kind of callout
+type calloutStyle struct {
+	class string
+	icon  string // Path of a 24x24 outline icon
+}
+
+var (
+	infoIcon     = "M13 16h-1v-4h-1m1-4h.01M21 12a9 9 0 11-18 0 9 9 0 0118 0z"
+	tipIcon      = "M9.663 17h4.673M12 3v1m6.364 1.636l-.707.707M21 12h-1M4 12H3m3.343-5.657l-.707-.707m2.828 9.9a5 5 0 117.072 0l-.548.547A3.374 3.374 0 0014 18.469V19a2 2 0 11-4 0v-.531c0-.895-.356-1.754-.988-2.386l-.548-.547z"
+	successIcon  = "M9 12l2 2 4-4m6 2a9 9 0 11-18 0 9 9 0 0118 0z"
+	questionIcon = "M8.228 9c.549-1.165 2.03-2 3.772-2 2.21 0 4 1.343 4 3 0 1.4-1.278 2.575-3.006 2.907-.542.104-.994.54-.994 1.093m0 3h.01M21 12a9 9 0 11-18 0 9 9 0 0118 0z"
+	warningIcon  = "M12 9v2m0 4h.01m-6.938 4h13.856c1.54 0 2.502-1.667 1.732-3L13.732 4c-.77-1.333-2.694-1.333-3.464 0L3.34 16c-.77 1.333.192 3 1.732 3z"
+	errorIcon    = "M10 14l2-2m0 0l2-2m-2 2l-2-2m2 2l2 2m7-2a9 9 0 11-18 0 9 9 0 0118 0z"
+	quoteIcon    = "M4 6h16M4 12h16M4 18h7"
+)
+
+// Styles of the GitHub alert and Obsidian callout kinds, other kinds look like notes
+var calloutStyles = map[string]calloutStyle{
+	"note":      {"alert-info", infoIcon},
+	"info":      {"alert-info", infoIcon},
+	"todo":      {"alert-info", successIcon},
+	"abstract":  {"alert-info", quoteIcon},
+	"summary":   {"alert-info", quoteIcon},
+	"tldr":      {"alert-info", quoteIcon},
+	"important": {"alert-info", infoIcon},
+	"tip":       {"alert-success", tipIcon},
+	"hint":      {"alert-success", tipIcon},
+	"success":   {"alert-success", successIcon},
+	"check":     {"alert-success", successIcon},
+	"done":      {"alert-success", successIcon},
+	"question":  {"", questionIcon},
+	"help":      {"", questionIcon},
+	"faq":       {"", questionIcon},
+	"warning":   {"alert-warning", warningIcon},
+	"attention": {"alert-warning", warningIcon},
+	"caution":   {"alert-error", warningIcon},
+	"danger":    {"alert-error", errorIcon},
+	"error":     {"alert-error", errorIcon},
+	"failure":   {"alert-error", errorIcon},
+	"fail":      {"alert-error", errorIcon},
+	"missing":   {"alert-error", errorIcon},
+	"bug":       {"alert-error", errorIcon},
+	"example":   {"", quoteIcon},
+	"quote":     {"", quoteIcon},
+	"cite":      {"", quoteIcon},
+}
+
+// renderCallout renders a callout as a daisyUI alert, foldable callouts as a <details> element
+func renderCallout(w io.Writer, callout *p_.Callout, entering bool) {
+	tag := "div"
+	if callout.Foldable {
+		tag = "details"
+	}
+
+	if !entering {
+		fmt.Fprintf(w, "</div></%s>\n", tag)
+		return
+	}
+
+	style, ok := calloutStyles[callout.Kind]
+	if !ok {
+		style = calloutStyles["note"]
+	}
+
+	attributes := fmt.Sprintf(` class="callout alert %s block my-4" data-callout="%s"`, style.class, html.EscapeString(callout.Kind))
+	if callout.Attribute != nil && callout.ID != nil {
+		attributes += fmt.Sprintf(` id="%s"`, html.EscapeString(string(callout.ID)))
+	}
+	if callout.Foldable && callout.Open {
+		attributes += " open"
+	} else if !callout.Foldable {
+		attributes += ` role="note"`
+	}
+	fmt.Fprintf(w, "<%s%s>", tag, attributes)
+}
+
+// renderCalloutTitle renders the title of a callout with its icon, as the summary of foldable callouts
+func renderCalloutTitle(w io.Writer, title *p_.CalloutTitle, entering bool) {
+	callout := title.GetParent().(*p_.Callout)
+	tag, classes := "div", "callout-title flex items-center gap-2 font-bold"
+	if callout.Foldable {
+		tag, classes = "summary", classes+" cursor-pointer"
+	}
+
+	if !entering {
+		fmt.Fprintf(w, `</%s><div class="callout-content">`, tag)
+		return
+	}
+
+	style, ok := calloutStyles[callout.Kind]
+	if !ok {
+		style = calloutStyles["note"]
+	}
+	fmt.Fprintf(w, `<%s class="%s">`, tag, classes)
+	fmt.Fprintf(w, `<svg xmlns="http://www.w3.org/2000/svg" fill="none" viewBox="0 0 24 24" class="h-6 w-6 shrink-0 stroke-current" aria-hidden="true"><path stroke-linecap="round" stroke-linejoin="round" stroke-width="2" d="%s"></path></svg>`, style.icon)
+}
@@ -0,0 +1,245 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"html"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"website/src"
+	"website/src/models"
+)
+
+var (
+	tagRegex       = regexp.MustCompile(`<[a-zA-Z][^>]*>`)
+	attributeRegex = regexp.MustCompile(`\s([\w-]+)="([^"]*)"`)
+)
+
+// linkProblem is a broken link, image or asset at a line of a page
+type linkProblem struct {
+	path string
+	line int
+	msg  string
+}
+
+func (p linkProblem) String() string {
+	return fmt.Sprintf("%s:%d: %s", p.path, p.line, p.msg)
+}
+
+// checkedPage is a rendered page with the element IDs links to it may point at
+type checkedPage struct {
+	file   models.File
+	source string
+	html   string
+	ids    map[string]bool
+}
+
+// checkLinks renders every page and checks that its links to pages, headings and assets of the site
+// resolve, and if external is set, that links to other sites do too.
+func checkLinks(external bool) ([]linkProblem, error) {
+	folder, err := siteTree("")
+	if err != nil {
+		return nil, err
+	}
+
+	pages := make(map[string]*checkedPage)
+	var order []*checkedPage
+	for _, file := range folder.AllFiles() {
+		mdPath := filepath.Join("public", file.Path+".md")
+		md, err := os.ReadFile(mdPath)
+		if err != nil {
+			return nil, err
+		}
+
+		rendered := renderMarkdown(src.ParseDocument(mdPath, md), mdPath)
+		page := &checkedPage{file: file, source: string(md), html: rendered.Content, ids: make(map[string]bool)}
+		for _, tag := range tagRegex.FindAllString(page.html, -1) {
+			if id := attribute(tag, "id"); id != "" {
+				page.ids[id] = true
+			}
+		}
+		pages[file.Path] = page
+		order = append(order, page)
+	}
+
+	var problems []linkProblem
+	externalLinks := make(map[string][]linkProblem)
+	for _, page := range order {
+		for _, tag := range tagRegex.FindAllString(page.html, -1) {
+			wikilink := attribute(tag, "data-wikilink")
+			report := func(reference string, format string, args ...any) {
+				if wikilink != "" {
+					reference = "[[" + wikilink
+				}
+				problems = append(problems, linkProblem{
+					path: filepath.Join("public", page.file.Path+".md"),
+					line: page.line(reference),
+					msg:  fmt.Sprintf(format, args...),
+				})
+			}
+
+			// Wiki links without a page are rendered without a link
+			if strings.Contains(attribute(tag, "class"), "wikilink-missing") {
+				report("", "broken link [[%s]]: %s", wikilink, attribute(tag, "title"))
+				continue
+			}
+
+			for _, name := range []string{"href", "src"} {
+				reference := attribute(tag, name)
+				if reference == "" {
+					continue
+				}
+
+				u, err := url.Parse(reference)
+				if err != nil {
+					report(reference, "invalid URL %q: %v", reference, err)
+					continue
+				}
+				if u.Scheme != "" || u.Host != "" {
+					if external && (u.Scheme == "http" || u.Scheme == "https") {
+						externalLinks[reference] = append(externalLinks[reference], linkProblem{
+							path: filepath.Join("public", page.file.Path+".md"),
+							line: page.line(reference),
+						})
+					}
+					continue
+				}
+
+				// Relative references are resolved against the page's URL, like the browser does
+				target := u.Path
+				relative := target != "" && !strings.HasPrefix(target, "/")
+				if target == "" {
+					target = "/page/" + page.file.Path
+				} else if relative {
+					target = path.Join("/page", path.Dir(page.file.Path), target)
+				}
+
+				if asset, ok := strings.CutPrefix(target, "/static/"); ok {
+					if _, err := os.Stat(filepath.Join("static", filepath.FromSlash(asset))); err != nil {
+						report(reference, "missing asset %q: no file static/%s", reference, asset)
+					}
+					continue
+				}
+
+				linked, ok := strings.CutPrefix(target, "/page/")
+				if !ok {
+					// Other routes are only linked to on purpose by their absolute path
+					if relative {
+						report(reference, "broken link %q: resolves to %s, outside the pages of the site", reference, target)
+					}
+					continue
+				}
+				if name == "src" {
+					report(reference, "broken %s %q: only files under /static/ are served as assets", tagName(tag), reference)
+					continue
+				}
+				linkedPage, ok := pages[linked]
+				if !ok {
+					report(reference, "broken link %q: no page public/%s.md", reference, linked)
+					continue
+				}
+				if u.Fragment != "" && !linkedPage.ids[u.Fragment] {
+					report(reference, "broken link %q: no heading with ID %q in public/%s.md", reference, u.Fragment, linked)
+				}
+			}
+		}
+	}
+
+	if external {
+		problems = append(problems, checkExternalLinks(externalLinks)...)
+	}
+	slices.SortStableFunc(problems, func(a, b linkProblem) int {
+		return cmp.Or(strings.Compare(a.path, b.path), cmp.Compare(a.line, b.line))
+	})
+	return problems, nil
+}
+
+// checkExternalLinks requests every external link once, reporting it wherever it is used if it fails
+func checkExternalLinks(links map[string][]linkProblem) []linkProblem {
+	client := &http.Client{Timeout: 10 * time.Second}
+	var problems []linkProblem
+	for link, uses := range links {
+		resp, err := client.Head(link)
+		if err == nil && resp.StatusCode == http.StatusMethodNotAllowed {
+			resp.Body.Close()
+			resp, err = client.Get(link)
+		}
+
+		msg := ""
+		if err != nil {
+			msg = fmt.Sprintf("broken external link %q: %v", link, err)
+		} else {
+			resp.Body.Close()
+			if resp.StatusCode >= 400 {
+				msg = fmt.Sprintf("broken external link %q: status %d", link, resp.StatusCode)
+			}
+		}
+		if msg == "" {
+			continue
+		}
+		for _, use := range uses {
+			use.msg = msg
+			problems = append(problems, use)
+		}
+	}
+	return problems
+}
+
+// line returns the line of the page's source a reference is first written on.
+// Generated links that can not be found in the source are reported at the first line.
+func (p *checkedPage) line(reference string) int {
+	if reference == "" {
+		return 1
+	}
+	for i, line := range strings.Split(p.source, "\n") {
+		if strings.Contains(line, reference) {
+			return i + 1
+		}
+	}
+	return 1
+}
+
+// attribute returns the unescaped value of an attribute of an HTML tag, or the empty string if it has none
+func attribute(tag string, name string) string {
+	for _, match := range attributeRegex.FindAllStringSubmatch(tag, -1) {
+		if match[1] == name {
+			return html.UnescapeString(match[2])
+		}
+	}
+	return ""
+}
+
+func tagName(tag string) string {
+	name, _, _ := strings.Cut(strings.TrimPrefix(tag, "<"), " ")
+	return strings.TrimSuffix(name, ">")
+}
+
+// runCheckLinks prints the problems checkLinks finds to w and returns the exit status of the command,
+// which is non-zero if there are any
+func runCheckLinks(w io.Writer, external bool) int {
+	problems, err := checkLinks(external)
+	if err != nil {
+		log.Println(err)
+		return 1
+	}
+
+	for _, problem := range problems {
+		fmt.Fprintln(w, problem)
+	}
+	if len(problems) > 0 {
+		fmt.Fprintf(w, "%d broken links\n", len(problems))
+		return 1
+	}
+	fmt.Fprintln(w, "All links are fine")
+	return 0
+}
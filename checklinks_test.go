@@ -0,0 +1,40 @@
+package main
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCheckLinks(t *testing.T) {
+	testSite(t, map[string]string{
+		"a.md": "# A\n\nSee [b](b), [c](sub/c#c) and [a heading](/page/b#b).\n\n" +
+			"[Gone](gone), [no heading](b#nope), ![missing](/static/missing.png) and [up](../outside).\n",
+		"b.md":     "# B\n\n![style](/static/style.css) and [the articles](/articles).\n",
+		"sub/c.md": "# C\n\nBack to [a](../a).\n",
+	})
+	writePage(t, filepath.Join("static", "style.css"), "")
+
+	var out bytes.Buffer
+	if status := runCheckLinks(&out, false); status == 0 {
+		t.Errorf("got status 0 for broken links")
+	}
+
+	want := []string{
+		`public/a.md:5: broken link "gone": no page public/gone.md`,
+		`public/a.md:5: broken link "b#nope": no heading with ID "nope" in public/b.md`,
+		`public/a.md:5: missing asset "/static/missing.png": no file static/missing.png`,
+		`public/a.md:5: broken link "../outside": resolves to /outside, outside the pages of the site`,
+		"4 broken links",
+	}
+	if got := strings.Split(strings.TrimSpace(out.String()), "\n"); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("got problems:\n%s\nwant:\n%s", out.String(), strings.Join(want, "\n"))
+	}
+
+	writePage(t, filepath.Join("public", "a.md"), "# A\n\nSee [b](b).\n")
+	out.Reset()
+	if status := runCheckLinks(&out, false); status != 0 || !strings.Contains(out.String(), "All links are fine") {
+		t.Fatalf("got status %d once the links are fixed:\n%s", status, out.String())
+	}
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"regexp"
+
+	p_ "website/src/parser"
+)
+
+// Site specific directives, on top of the built in ones of the parser package
+func init() {
+	directives.Register("youtube", p_.Raw, youtubeDirective)
+	directives.Register("note", p_.Rendered, noteDirective)
+}
+
+var youtubeIdRegex = regexp.MustCompile(`^[\w-]+$`)
+
+// youtubeDirective embeds a YouTube video
+//
+//	{youtube dQw4w9WgXcQ}
+//	{youtube id="dQw4w9WgXcQ" title="Never gonna give you up"}
+func youtubeDirective(call *p_.Call) (string, error) {
+	id := call.Args.Get("id")
+	if id == "" && len(call.Args.Positional) > 0 {
+		id = call.Args.Positional[0]
+	}
+	if id == "" {
+		return "", errors.New("missing video id")
+	}
+	if !youtubeIdRegex.MatchString(id) {
+		return "", fmt.Errorf("invalid video id %q", id)
+	}
+
+	title := call.Args.Get("title")
+	if title == "" {
+		title = "YouTube video"
+	}
+
+	return fmt.Sprintf(`<iframe class="w-full aspect-video rounded-box" src="https://www.youtube-nocookie.com/embed/%s" title="%s" loading="lazy" allowfullscreen></iframe>`, id, html.EscapeString(title)), nil
+}
+
+// noteDirective renders a highlighted box around Markdown content
+//
+//	{note title="Heads up"}
+//	Some **important** text
+//	{/note}
+func noteDirective(call *p_.Call) (string, error) {
+	content := call.Content
+	if !call.Block {
+		content = html.EscapeString(call.Args.Raw)
+	}
+
+	title := ""
+	if t := call.Args.Get("title"); t != "" && call.Block {
+		title = fmt.Sprintf(`<h3 class="font-bold">%s</h3>`, html.EscapeString(t))
+	}
+
+	return fmt.Sprintf(`<div role="note" class="alert alert-info block my-4">%s%s</div>`, title, content), nil
+}
@@ -13,6 +13,7 @@ require (
 	github.com/gomarkdown/markdown v0.0.0-20250311123330-531bef5e742b
 	github.com/gomarkdown/mdtohtml v0.0.0-20240124153210-d773061d1585 // indirect
 	github.com/google/uuid v1.6.0
+	github.com/pelletier/go-toml/v2 v2.4.3
 	github.com/rickb777/path v1.3.1 // indirect
 	github.com/rickb777/servefiles/v3 v3.9.5
 	github.com/spf13/afero v1.14.0 // indirect
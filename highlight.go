@@ -0,0 +1,147 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/alecthomas/chroma/v2"
+	chromahtml "github.com/alecthomas/chroma/v2/formatters/html"
+	"github.com/alecthomas/chroma/v2/lexers"
+	"github.com/alecthomas/chroma/v2/styles"
+	"github.com/gomarkdown/markdown/ast"
+
+	"website/src"
+	p_ "website/src/parser"
+)
+
+// Code blocks are highlighted with CSS classes, the colors come from the stylesheet of a chroma style,
+// served at /static/chroma/<style>.css. Pages link the stylesheets of a light and a dark style.
+
+// renderCodeBlock renders a code block as a figure with the title from its info string above the highlighted code
+func (r *CustomRenderer) renderCodeBlock(w io.Writer, node *ast.CodeBlock) {
+	// Problems with the info string are reported at their line when the page is expanded
+	info, _ := p_.ParseCodeInfo(string(node.Info))
+	code := string(node.Literal)
+
+	var lexer chroma.Lexer
+	if info.NoHighlight {
+		lexer = lexers.Fallback
+	} else if info.Language == "" {
+		lexer = lexers.Analyse(code)
+	} else {
+		lexer = lexers.Get(info.Language)
+	}
+
+	if lexer == nil {
+		lexer = lexers.Fallback
+	}
+
+	formatter := chromahtml.New(
+		chromahtml.WithClasses(true),
+		chromahtml.WithLineNumbers(info.LineNumbers),
+		chromahtml.BaseLineNumber(info.Start),
+		chromahtml.HighlightLines(info.Highlight),
+	)
+	iterator, _ := lexer.Tokenise(nil, code)
+
+	// The style is only used for inline styles, which classes replace
+	formattedCode := &strings.Builder{}
+	if err := formatter.Format(formattedCode, styles.Fallback, iterator); err != nil {
+		log.Println("Error formatting code:", err)
+		return
+	}
+
+	fmt.Fprintf(w, `<figure class="code-block">`)
+	if info.Title != "" {
+		fmt.Fprintf(w, `<figcaption class="code-title">%s</figcaption>`, html.EscapeString(info.Title))
+	}
+	fmt.Fprintf(w, "%s", formattedCode)
+	fmt.Fprintf(w, `</figure>`)
+}
+
+// codeStyles returns the light and dark code style of a page, from its frontmatter or else the site config
+func codeStyles(fm src.Frontmatter) (light string, dark string) {
+	light, dark = site.CodeStyle, site.CodeStyleDark
+	if fm.CodeStyle != "" {
+		if src.IsCodeStyle(fm.CodeStyle) {
+			light = fm.CodeStyle
+		} else {
+			log.Printf("Unknown code style %q in frontmatter", fm.CodeStyle)
+		}
+	}
+	if fm.CodeStyleDark != "" {
+		if src.IsCodeStyle(fm.CodeStyleDark) {
+			dark = fm.CodeStyleDark
+		} else {
+			log.Printf("Unknown code style %q in frontmatter", fm.CodeStyleDark)
+		}
+	}
+	return light, dark
+}
+
+// writeCodeStyle writes the stylesheet of the chroma style name
+func writeCodeStyle(w io.Writer, name string) error {
+	style, ok := styles.Registry[name]
+	if !ok {
+		return fmt.Errorf("unknown code style %q", name)
+	}
+
+	// Rules for line numbers are only written by a formatter that shows them
+	formatter := chromahtml.New(chromahtml.WithClasses(true), chromahtml.WithLineNumbers(true))
+	css := &strings.Builder{}
+	if err := formatter.WriteCSS(css, style); err != nil {
+		return err
+	}
+
+	// The background is that of the .chroma wrapper already, .bg is only for standalone documents
+	for line := range strings.Lines(css.String()) {
+		if !strings.HasPrefix(line, "/* Background */") {
+			if _, err := io.WriteString(w, line); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func handleCodeStyle(w http.ResponseWriter, r *http.Request) {
+	name, ok := strings.CutSuffix(r.PathValue("file"), ".css")
+	if !ok || !src.IsCodeStyle(name) {
+		http.NotFound(w, r)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/css; charset=utf-8")
+	if err := writeCodeStyle(w, name); err != nil {
+		log.Println("Error writing code style:", err)
+	}
+}
+
+// buildCodeStyles writes the stylesheet of every chroma style into outDir, as pages may pick any of them
+func buildCodeStyles(outDir string) error {
+	dir := filepath.Join(outDir, "static", "chroma")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	for _, name := range styles.Names() {
+		file, err := os.Create(filepath.Join(dir, name+".css"))
+		if err != nil {
+			return err
+		}
+		err = writeCodeStyle(file, name)
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/alecthomas/chroma/v2/styles"
+)
+
+func TestCodeStyles(t *testing.T) {
+	testSite(t, map[string]string{
+		"a.md": "---\ncode_style: monokai\ncode_style_dark: nope\n---\n# A\n",
+		"b.md": "# B\n",
+	})
+	if err := os.MkdirAll("static", 0755); err != nil {
+		t.Fatal(err)
+	}
+	router := newRouter()
+
+	// A page may override the styles of the site config, unknown styles are left out
+	for page, want := range map[string][2]string{"/page/a": {"monokai", site.CodeStyleDark}, "/page/b": {site.CodeStyle, site.CodeStyleDark}} {
+		_, body := get(router, page)
+		light := `href="/static/chroma/` + want[0] + `.css" media="(prefers-color-scheme: light)"`
+		dark := `href="/static/chroma/` + want[1] + `.css" media="(prefers-color-scheme: dark)"`
+		if !strings.Contains(body, light) || !strings.Contains(body, dark) {
+			t.Errorf("%s does not link the styles %v:\n%s", page, want, body)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/static/chroma/monokai.css", nil))
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "text/css; charset=utf-8" {
+		t.Fatalf("got status %d and content type %q", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	if css := rec.Body.String(); !strings.Contains(css, ".chroma") || !strings.Contains(css, ".lnt") || strings.Contains(css, "Background") {
+		t.Errorf("unexpected stylesheet:\n%s", css)
+	}
+	for _, route := range []string{"/static/chroma/nope.css", "/static/chroma/monokai"} {
+		if status, _ := get(router, route); status != http.StatusNotFound {
+			t.Errorf("got status %d for %s", status, route)
+		}
+	}
+
+	// The built site has the stylesheet of every style, as the server sends it
+	if err := buildSite(router, "dist"); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range styles.Names() {
+		built, err := os.ReadFile(filepath.Join("dist", "static", "chroma", name+".css"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, served := get(router, "/static/chroma/"+name+".css"); string(built) != served {
+			t.Errorf("built stylesheet of %s differs from the served one", name)
+		}
+	}
+}
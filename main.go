@@ -1,21 +1,23 @@
 package main
 
 import (
+	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
+	stdhtml "html"
 	"io"
+	"io/fs"
 	"log"
 	"net/http"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 	"website/src"
 	"website/templates"
 
-	"github.com/alecthomas/chroma/v2"
-	"github.com/alecthomas/chroma/v2/formatters"
-	"github.com/alecthomas/chroma/v2/lexers"
-	"github.com/alecthomas/chroma/v2/styles"
 	"github.com/gomarkdown/markdown"
 	"github.com/gomarkdown/markdown/ast"
 	"github.com/gomarkdown/markdown/html"
@@ -23,6 +25,8 @@ import (
 	"github.com/google/uuid"
 	"github.com/rickb777/servefiles/v3"
 
+	"website/src/cache"
+	"website/src/mathml"
 	"website/src/models"
 	p_ "website/src/parser"
 )
@@ -35,7 +39,7 @@ func handleIndex(w http.ResponseWriter, r *http.Request) {
 
 func handleArticles(w http.ResponseWriter, r *http.Request) {
 	// Get all known articles for navigation purposes
-	folder, err := models.FileTree("public", "")
+	folder, err := siteTree("")
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		log.Fatal(err)
@@ -48,165 +52,443 @@ func handleArticles(w http.ResponseWriter, r *http.Request) {
 	_ = component.Render(ctx, w)
 }
 
+// renderedPage is the output of the rendering pipeline for a single page
+type renderedPage struct {
+	Content string
+	Toc     string            // Table of contents, empty if the page has no headings
+	Deps    map[string]string // Hashes of other files the page was rendered from, such as chart data
+	MathJax bool              // Page has math that MathJax has to typeset in the browser
+	Words   int               // Number of words in the text of the page, for its reading time
+
+	// Pages with wiki links depend on every page, as a link to a page that is added, published or taken down
+	// resolves differently. SiteStamp is the content stamp of public/ they were rendered at, empty for other pages,
+	// and SiteExpires the next scheduled change to the published pages after it.
+	SiteStamp   string
+	SiteExpires time.Time
+}
+
+// depsChanged reports whether any file the page depends on has changed since it was rendered,
+// where stamp is the current content stamp of public/, or the empty string if it could not be found
+func (p renderedPage) depsChanged(stamp string) bool {
+	if p.SiteStamp != "" {
+		if !p.SiteExpires.IsZero() && !time.Now().Before(p.SiteExpires) {
+			return true
+		}
+		if stamp == "" || stamp != p.SiteStamp {
+			return true
+		}
+	}
+	for path, hash := range p.Deps {
+		if fileHash(path) != hash {
+			return true
+		}
+	}
+	return false
+}
+
+// fileHash hashes the contents of a file, a missing file hashes to the empty string
+func fileHash(path string) string {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	return cache.Hash(content)
+}
+
+// renderVersion identifies the renderer and the site config pages are rendered with, so pages persisted
+// to the cache directory are rendered again after a new build is deployed or the config changes
+func renderVersion() string {
+	var exe []byte
+	if path, err := os.Executable(); err == nil {
+		exe, _ = os.ReadFile(path)
+	}
+	config, _ := json.Marshal(site)
+	return cache.Hash(exe, config)
+}
+
+// Rendered pages, keyed by resource path
+var pageCache = cache.New[renderedPage]("", "")
+
+// Site configuration, loaded from the file given by -config
+var site = src.DefaultConfig()
+
 type CustomRenderer struct {
 	*html.Renderer
+	MathJax bool // Set when the page has math that could not be converted to MathML, which MathJax typesets in the browser
+}
+
+// renderMath writes TeX math as MathML, or between the delimiters MathJax looks for if
+// the TeX uses constructs the converter does not handle
+func (r *CustomRenderer) renderMath(w io.Writer, tex []byte, display bool) {
+	if mathML, err := mathml.Convert(string(tex), display); err == nil {
+		io.WriteString(w, mathML)
+		return
+	}
+
+	r.MathJax = true
+	if display {
+		io.WriteString(w, `<span class="math display">\[`)
+		html.EscapeHTML(w, tex)
+		io.WriteString(w, `\]</span>`)
+	} else {
+		io.WriteString(w, `<span class="math inline">\(`)
+		html.EscapeHTML(w, tex)
+		io.WriteString(w, `\)</span>`)
+	}
 }
 
 func (r *CustomRenderer) RenderNode(w io.Writer, node ast.Node, entering bool) ast.WalkStatus {
 	switch node := node.(type) {
 	case *ast.CodeBlock:
 		if entering {
-			// Custom code block rendering logic
-			lang := string(node.Info)
-			code := string(node.Literal)
-
-			var lexer chroma.Lexer
-			if lang == "" {
-				lexer = lexers.Analyse(code)
-			} else {
-				lexer = lexers.Get(lang)
-			}
+			r.renderCodeBlock(w, node)
+		}
+		return ast.GoToNext
+	case *p_.Callout:
+		renderCallout(w, node, entering)
+		return ast.GoToNext
+	case *p_.CalloutTitle:
+		renderCalloutTitle(w, node, entering)
+		return ast.GoToNext
+	case *p_.WikiLink:
+		// Wiki links left in the document are broken
+		fmt.Fprintf(w, `<span class="wikilink wikilink-missing text-error underline decoration-dotted cursor-help" title="%s" data-wikilink="%s">%s</span>`,
+			stdhtml.EscapeString(node.Err.Error()), stdhtml.EscapeString(node.Source()), stdhtml.EscapeString(node.Label))
+		return ast.GoToNext
+	case *ast.Math:
+		r.renderMath(w, node.Literal, false)
+		return ast.GoToNext
+	case *p_.DisplayMath:
+		r.renderMath(w, node.Literal, true)
+		return ast.GoToNext
+	case *ast.MathBlock:
+		if entering {
+			r.renderMath(w, node.Literal, true)
+		}
+		return ast.GoToNext
+	default:
+		return r.Renderer.RenderNode(w, node, entering)
+	}
+}
 
-			if lexer == nil {
-				lexer = lexers.Fallback
-			}
+// Directives available in pages, site specific directives are registered in directives.go
+var directives = p_.NewRegistry()
 
-			style := styles.Get(styles.CatppuccinFrappe.Name) // Default style
-			if style == nil {
-				style = styles.Fallback
+// parseMarkdown expands the directives in a Markdown source starting at line of the page and parses it,
+// giving its headings IDs that are unique within the page, and turning blockquotes marked with [!KIND]
+// into callouts and [[wiki links]] into links to pages. Its footnotes are numbered when its directives are inserted.
+func parseMarkdown(ctx *p_.RenderContext, source string, line int) (ast.Node, []*p_.Directive) {
+	expanded, found := directives.Expand(ctx, source, line)
+	doc := newParser().Parse([]byte(expanded))
+
+	p_.AssignHeadingIDs(ctx, doc)
+	p_.ProcessCallouts(doc)
+	p_.ResolveWikiLinks(ctx, doc)
+	return doc, found
+}
+
+// parseInline is like parseMarkdown, for fragments inside a paragraph. Only inline Markdown
+// is parsed, into a document which renders without any wrapping element.
+func parseInline(ctx *p_.RenderContext, source string, line int) (ast.Node, []*p_.Directive) {
+	expanded, found := directives.Expand(ctx, source, line)
+	doc := &ast.Document{}
+	p := newParser()
+	p.Inline(doc, []byte(expanded))
+	p_.InlineFootnotes(p, doc)
+	p_.ResolveWikiLinks(ctx, doc)
+	return doc, found
+}
+
+func newParser() *parser.Parser {
+	extensions := parser.CommonExtensions | parser.NoEmptyLineBeforeBlock | parser.Footnotes
+	p := parser.NewWithExtensions(extensions &^ parser.MathJax)
+	p_.RegisterMath(p)
+	p_.RegisterWikiLinks(p)
+	return p
+}
+
+func newRenderer() *CustomRenderer {
+	htmlFlags := html.CommonFlags | html.HrefTargetBlank | html.FootnoteReturnLinks
+	opts := html.RendererOptions{Flags: htmlFlags, FootnoteReturnLinkContents: "&#8617;"}
+	return &CustomRenderer{Renderer: html.NewRenderer(opts)}
+}
+
+// renderMarkdown runs the body of a page through the full rendering pipeline.
+// Files referenced by the page are resolved against the directory of path.
+func renderMarkdown(page src.Document, path string) renderedPage {
+	fm := page.Frontmatter
+	ctx := p_.NewRenderContext(path)
+	ctx.TocDepth = fm.TocDepth
+
+	// Fragments, like the content of sidenotes, are rendered with the same pipeline as the page
+	mathJax := false
+	renderFragment := func(doc ast.Node, found []*p_.Directive) string {
+		directives.Insert(ctx, doc, found)
+
+		renderer := newRenderer()
+		rendered := markdown.Render(doc, renderer)
+		mathJax = mathJax || renderer.MathJax
+		return string(rendered)
+	}
+	ctx.Render = func(source string, line int) string {
+		return renderFragment(parseMarkdown(ctx, source, line))
+	}
+	ctx.RenderInline = func(source string, line int) string {
+		return renderFragment(parseInline(ctx, source, line))
+	}
+
+	// Pages are only indexed for pages with wiki links. Those pages depend on the whole site, so their links
+	// are resolved again when a page is added, moved, published or taken down, including pages they failed to find.
+	var index *models.PageIndex
+	var siteStamp string
+	var siteExpires time.Time
+	ctx.ResolvePage = func(target string) (string, error) {
+		if index == nil {
+			now := time.Now()
+			stamp, err := contentStamp("public")
+			if err != nil {
+				return "", err
 			}
-			formatter := formatters.Get("html")
-			if formatter == nil {
-				formatter = formatters.Fallback
+			next, _, err := nextScheduledChange(now)
+			if err != nil {
+				return "", err
 			}
-			reader := strings.NewReader(code)
-			contents, _ := io.ReadAll(reader)
-			iterator, _ := lexer.Tokenise(nil, string(contents))
+			siteStamp, siteExpires = stamp, next.At
 
-			var formattedCode string
-			formattedCodeWriter := &strings.Builder{}
-			err := formatter.Format(formattedCodeWriter, style, iterator)
+			folder, err := siteTree("")
 			if err != nil {
-				log.Println("Error formatting code:", err)
-				return ast.GoToNext
-			}
-			formattedCode = formattedCodeWriter.String()
-
-			// Postprocess code block to remove body tags, html tags
-			codeBlock := strings.ReplaceAll(formattedCode, "<body>", "")
-			codeBlock = strings.ReplaceAll(codeBlock, "</body>", "")
-			codeBlock = strings.ReplaceAll(codeBlock, "<body class=\"bg\">", "")
-			codeBlock = strings.ReplaceAll(codeBlock, "<html>", "")
-			codeBlock = strings.ReplaceAll(codeBlock, "</html>", "")
-			// Remove unnecessary styles
-			bgLine := ""
-			bodyLine := ""
-			lines := strings.SplitSeq(codeBlock, "\n")
-			for line := range lines {
-				if strings.Contains(line, "/* Background */") {
-					bgLine = line
-				} else if strings.Contains(line, "body {") { // todo: use more robust method
-					bodyLine = line
-				}
+				return "", err
 			}
+			index = models.NewPageIndex(folder, pageTitle)
+		}
+
+		page, err := index.Resolve(target)
+		if err != nil {
+			return "", err
+		}
+		ctx.AddDependency(filepath.Join("public", page+".md"))
+		return "/page/" + page, nil
+	}
 
-			codeBlock = strings.ReplaceAll(codeBlock, bgLine, "")
-			codeBlock = strings.ReplaceAll(codeBlock, bodyLine, "")
+	// Directives are swapped for placeholders, and rendered once the Markdown is parsed
+	doc, found := parseMarkdown(ctx, page.Body, page.BodyLine)
 
-			// fmt.Fprintf(w, `<div class="mockup-code bg-[#303446]">`)
-			fmt.Fprintf(w, `<div>`)
-			fmt.Fprintf(w, "%s", codeBlock)
-			fmt.Fprintf(w, `</div>`)
-			return ast.GoToNext
+	directives.Insert(ctx, doc, found)
+	for _, err := range ctx.Errors() {
+		if _, ok := err.(*p_.SyntaxError); ok {
+			log.Printf("%s:%v", path, err)
+		} else {
+			log.Printf("%s: %v", path, err)
 		}
-		return ast.GoToNext
-	default:
-		return r.Renderer.RenderNode(w, node, entering)
 	}
+
+	// Table of contents in the sidebar, {toc} places one in the page itself
+	toc := ""
+	if fm.Toc {
+		toc = p_.RenderToc(p_.BuildToc(doc, fm.TocDepth))
+	}
+
+	renderer := newRenderer()
+	renderedBytes := markdown.Render(doc, renderer)
+
+	rendered := renderedPage{Content: string(renderedBytes), Toc: toc, Deps: make(map[string]string), MathJax: mathJax || renderer.MathJax, Words: p_.WordCount(doc), SiteStamp: siteStamp, SiteExpires: siteExpires}
+	for _, dep := range ctx.Dependencies() {
+		rendered.Deps[dep] = fileHash(dep)
+	}
+	return rendered
 }
 
-func handleDynamic(w http.ResponseWriter, r *http.Request) {
-	resource := r.PathValue("resource")
+// frontmatterCache holds the frontmatter of the pages under public/, so listing the site only reads the pages that changed
+type frontmatterCache struct {
+	mu      sync.Mutex
+	entries map[string]cachedFrontmatter // By the path of the page's file
+}
 
-	// Get all known dynamic files for navigation purposes
-	folder, err := models.FileTree("public", resource)
+// cachedFrontmatter is the frontmatter of a page as of the size and modification time of its file
+type cachedFrontmatter struct {
+	size        int64
+	modTime     time.Time
+	frontmatter src.Frontmatter
+}
+
+var frontmatters = &frontmatterCache{entries: make(map[string]cachedFrontmatter)}
+
+// pageFrontmatter returns the frontmatter of a page, or an empty one if it can not be read.
+// Problems with the frontmatter are reported when the page itself is rendered.
+func pageFrontmatter(file models.File) src.Frontmatter {
+	path := filepath.Join("public", file.Path+".md")
+	info, err := os.Stat(path)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		log.Fatal(err)
-		return
+		return src.Frontmatter{}
 	}
 
-	// MD from static
-	// TODO: check if HTML file exists
-	mdPath := filepath.Join("public", resource+".md")
-	md, err := os.ReadFile(mdPath)
+	frontmatters.mu.Lock()
+	cached, ok := frontmatters.entries[path]
+	frontmatters.mu.Unlock()
+	if ok && cached.size == info.Size() && cached.modTime.Equal(info.ModTime()) {
+		return cached.frontmatter
+	}
+
+	doc, err := loadPage(file)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		log.Fatal(err)
+		return src.Frontmatter{}
 	}
+	return doc.Frontmatter
+}
 
-	// Remove frontmatter before parsing
-	mdNoFrontmatter := src.RemoveFrontmatter(string(md))
+// loadPage reads and parses a page, keeping its frontmatter for pageFrontmatter
+func loadPage(file models.File) (src.Document, error) {
+	path := filepath.Join("public", file.Path+".md")
+	info, err := os.Stat(path)
+	if err != nil {
+		return src.Document{}, err
+	}
+	doc, err := src.LoadDocument(path)
+	if err != nil {
+		return src.Document{}, err
+	}
 
-	extensions := parser.CommonExtensions | parser.AutoHeadingIDs | parser.NoEmptyLineBeforeBlock
-	p := parser.NewWithExtensions(extensions)
-	doc := p.Parse([]byte(mdNoFrontmatter))
+	frontmatters.mu.Lock()
+	frontmatters.entries[path] = cachedFrontmatter{size: info.Size(), modTime: info.ModTime(), frontmatter: doc.Frontmatter}
+	frontmatters.mu.Unlock()
+	return doc, nil
+}
 
-	htmlFlags := html.CommonFlags | html.HrefTargetBlank
-	opts := html.RendererOptions{Flags: htmlFlags}
-	renderer := html.NewRenderer(opts)
-	customRenderer := &CustomRenderer{Renderer: renderer}
+// pageTitle returns the title in the frontmatter of a page, or the empty string if it has none
+func pageTitle(file models.File) string {
+	return pageFrontmatter(file).Title
+}
 
-	renderedBytes := markdown.Render(doc, customRenderer)
+// siteTree returns the pages under public/ with the page at selected marked as selected.
+// Drafts and pages outside their publishing window are left out, unless the site is previewed,
+// where they are marked instead.
+func siteTree(selected string) (models.Folder, error) {
+	folder, err := models.FileTree("public", selected)
+	if err != nil {
+		return folder, err
+	}
 
-	// Handle sidenotes
-	renderedBytes = p_.ProcessSidenotes(renderedBytes)
+	now := time.Now()
+	return folder.Filter(func(file *models.File) bool {
+		fm := pageFrontmatter(*file)
+		published := fm.PublishedAt(now, site.Location())
+		file.Draft, file.Unpublished = fm.Draft, !published
+		file.Desc, file.Params = fm.Desc, fm.Params
+		file.Tags, file.Categories = fm.Tags, fm.Categories
+		return (!file.Draft && published) || site.Preview
+	}), nil
+}
 
-	// Handle $$ inline latex
-	// Replaces $$...$$ with $<div class="inline-latex-block">...</div>$
-	parsedBytes := make([]byte, 0)
-	inInlineLatex := false
-	prevWasDollarSign := false
-	for _, b := range renderedBytes {
-		if b == '$' && !prevWasDollarSign {
-			prevWasDollarSign = true
-		}
+// scheduledChange is a page being published or expiring at a time
+type scheduledChange struct {
+	At     time.Time
+	Page   string // Path of the page's Markdown source
+	Expiry bool   // The page expires, rather than being published
+}
 
-		if b != '$' && prevWasDollarSign {
-			prevWasDollarSign = false
-		} else if prevWasDollarSign && !inInlineLatex {
-			inInlineLatex = true
-			startInlineBlock := "<div class=\"inline-latex-block\">"
-			parsedBytes = append(parsedBytes, []byte(startInlineBlock)...)
-			continue
-		} else if prevWasDollarSign && inInlineLatex {
-			inInlineLatex = false
-			endInlineBlock := "</div>"
-			parsedBytes = append(parsedBytes, []byte(endInlineBlock)...)
+func (c scheduledChange) String() string {
+	action := "is published"
+	if c.Expiry {
+		action = "expires"
+	}
+	return fmt.Sprintf("%s %s at %s", c.Page, action, c.At.Format(time.RFC3339))
+}
+
+// nextScheduledChange returns the first change after now to the pages that are published, if there is one.
+// Drafts are never published, so their schedules are left out.
+func nextScheduledChange(now time.Time) (scheduledChange, bool, error) {
+	folder, err := models.FileTree("public", "")
+	if err != nil {
+		return scheduledChange{}, false, err
+	}
+
+	var next scheduledChange
+	for _, file := range folder.AllFiles() {
+		fm := pageFrontmatter(file)
+		if fm.Draft {
 			continue
 		}
+		publish, expire := fm.Schedule(site.Location())
+
+		page := filepath.Join("public", file.Path+".md")
+		for _, change := range []scheduledChange{{At: publish, Page: page}, {At: expire, Page: page, Expiry: true}} {
+			if change.At.After(now) && (next.At.IsZero() || change.At.Before(next.At)) {
+				next = change
+			}
+		}
+	}
+	return next, !next.At.IsZero(), nil
+}
+
+// getPage returns the rendered page for resource from the cache, rendering it again
+// if its source or any file it depends on has changed. Stamp is the current content stamp of public/.
+func getPage(resource string, md []byte, doc src.Document, path string, stamp string) (renderedPage, error) {
+	render := func() (renderedPage, error) {
+		return renderMarkdown(doc, path), nil
+	}
 
-		parsedBytes = append(parsedBytes, b)
+	page, err := pageCache.Get(resource, md, render)
+	if err != nil || !page.depsChanged(stamp) {
+		return page, err
 	}
 
-	// Save the HTML
-	err = os.WriteFile(strings.Replace(mdPath, ".md", "", 1)+".html", parsedBytes, 0644)
+	pageCache.Invalidate(resource)
+	return pageCache.Get(resource, md, render)
+}
+
+func handleDynamic(w http.ResponseWriter, r *http.Request) {
+	resource := r.PathValue("resource")
+
+	// Get all known dynamic files for navigation purposes
+	folder, err := siteTree(resource)
 	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
 		log.Fatal(err)
 		return
 	}
 
-	// HTML from static
-	resource = strings.Replace(r.PathValue("resource"), ".md", "", 1)
+	// MD from static
+	mdPath := filepath.Join("public", resource+".md")
+	md, err := os.ReadFile(mdPath)
+	if errors.Is(err, fs.ErrNotExist) {
+		http.NotFound(w, r)
+		return
+	} else if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		log.Println("Error reading page:", err)
+		return
+	}
+
+	// Frontmatter, keys with problems are left out
+	doc := src.ParseDocument(mdPath, md)
+	for _, problem := range doc.Problems {
+		log.Println(problem)
+	}
+	fm := doc.Frontmatter
+
+	// Drafts and pages outside their publishing window only exist while previewing the site
+	published := fm.PublishedAt(time.Now(), site.Location())
+	if (fm.Draft || !published) && !site.Preview {
+		http.NotFound(w, r)
+		return
+	}
 
-	path := filepath.Join("public", resource+".html")
-	contentBytes, err := os.ReadFile(path)
+	// Whether any page has changed, found once for the cached page and the backlinks
+	stamp, err := contentStamp("public")
+	if err != nil {
+		log.Println("Error checking for changed pages:", err)
+		stamp = ""
+	}
+
+	// Rendered HTML, only re-rendered when the source has changed
+	page, err := getPage(resource, md, doc, mdPath, stamp)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
-		log.Fatal(err)
+		log.Println("Error rendering page:", err)
 		return
 	}
-	content := string(contentBytes)
+
+	resource = strings.Replace(r.PathValue("resource"), ".md", "", 1)
 
 	// Fancy breadcrumbs stuff
 	splitResource := strings.Split(resource, "/")
@@ -216,19 +498,14 @@ func handleDynamic(w http.ResponseWriter, r *http.Request) {
 	// 	}
 	// }
 
-	// Frontmatter
-	frontmatter, err := src.ScanFrontmatter(mdPath)
-	if err != nil {
-		log.Fatal("Error scanning frontmatter:", err)
-	}
-	parsedFm, err := src.ParseFrontmatter(frontmatter)
-	if err != nil {
-		log.Println("Error parsing frontmatter:", err)
+	wpm := fm.WPM
+	if wpm <= 0 {
+		wpm = site.WPM
 	}
+	readingTime := src.ReadingTime(page.Words, wpm)
 
-	// TODO: table of contents
-
-	component := templates.Page(folder, splitResource, *parsedFm, content)
+	lightStyle, darkStyle := codeStyles(fm)
+	component := templates.Page(folder, splitResource, fm, published, readingTime, page.Content, page.Toc, page.MathJax, lightStyle, darkStyle, links.Backlinks(resource, stamp))
 	ctx := r.Context()
 	_ = component.Render(ctx, w)
 }
@@ -290,13 +567,65 @@ func Authentication(next http.Handler) http.Handler {
 	})
 }
 
-func main() {
+func newRouter() *http.ServeMux {
 	router := http.NewServeMux()
 	router.HandleFunc("GET /", handleIndex)
 	router.HandleFunc("GET /articles", handleArticles)
 	router.HandleFunc("GET /page/{resource...}", handleDynamic)
+	router.HandleFunc("GET /static/chroma/{file}", handleCodeStyle)
+	for _, taxonomy := range taxonomies {
+		router.HandleFunc("GET /"+taxonomy.name, handleTaxonomy(taxonomy))
+		router.HandleFunc("GET /"+taxonomy.name+"/{term}", handleTaxonomyTerm(taxonomy))
+	}
 	static := servefiles.NewAssetHandler("./static/").WithMaxAge(time.Second) // todo: different time on deploy, ex hour
 	router.Handle("GET /static/", http.StripPrefix("/static/", static))
+	return router
+}
+
+func main() {
+	cacheDir := flag.String("cache-dir", "", "directory to persist rendered pages to, in-memory only if empty")
+	configPath := flag.String("config", "site.yaml", "site configuration file")
+	preview := flag.Bool("preview", false, "show drafts, overriding preview in the site configuration")
+	flag.Parse()
+
+	config, err := src.LoadConfig(*configPath)
+	if err != nil {
+		log.Fatal("Error loading config: ", err)
+	}
+	site = config
+	site.Preview = site.Preview || *preview
+
+	pageCache = cache.New[renderedPage](*cacheDir, renderVersion())
+
+	switch flag.Arg(0) {
+	case "build":
+		buildFlags := flag.NewFlagSet("build", flag.ExitOnError)
+		out := buildFlags.String("out", "dist", "directory to write the static site to")
+		_ = buildFlags.Parse(flag.Args()[1:])
+
+		if err := buildSite(newRouter(), *out); err != nil {
+			log.Fatal(err)
+		}
+		fmt.Println("Static site written to", *out)
+
+		// The site has to be built again when a page is published or expires
+		next, ok, err := nextScheduledChange(time.Now())
+		if err != nil {
+			log.Fatal(err)
+		}
+		if ok {
+			fmt.Println("Next scheduled change:", next)
+		}
+		return
+	case "check-links":
+		checkFlags := flag.NewFlagSet("check-links", flag.ExitOnError)
+		external := checkFlags.Bool("external", false, "also check links to other sites, which needs network access")
+		_ = checkFlags.Parse(flag.Args()[1:])
+
+		os.Exit(runCheckLinks(os.Stdout, *external))
+	}
+
+	router := newRouter()
 
 	stack := CreateStack(Logging)
 
@@ -307,7 +636,7 @@ func main() {
 
 	fmt.Println("Server running on port :8080")
 
-	err := server.ListenAndServe()
+	err = server.ListenAndServe()
 	if err != nil {
 		panic(err)
 	}
@@ -0,0 +1,394 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"website/src"
+	"website/src/cache"
+	"website/src/models"
+	"website/templates"
+)
+
+func TestRenderMarkdownConcurrently(t *testing.T) {
+	md := ("# Title {sidenote one}\n\nText {sidenote two} and {footnote: a note}.\n\n## Title\n\nMore {sidenote three}.\n")
+	want := renderMarkdown(src.Document{Body: md, BodyLine: 1}, "public/test.md").Content
+
+	for _, id := range []string{`id="sidenote-1"`, `id="sidenote-2"`, `id="sidenote-3"`, `id="title"`, `id="title-1"`, `href="#fn:1"`} {
+		if !strings.Contains(want, id) {
+			t.Fatalf("rendered page is missing %s:\n%s", id, want)
+		}
+	}
+
+	// Every render numbers its own sidenotes, footnotes and headings from the start
+	var wg sync.WaitGroup
+	for range 16 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if got := renderMarkdown(src.Document{Body: md, BodyLine: 1}, "public/test.md").Content; got != want {
+				t.Errorf("concurrent render differs:\n%s", got)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestFootnotesInSidenotes(t *testing.T) {
+	tests := []struct {
+		name     string
+		md       string
+		sidenote string   // End of the sidenote, with the reference to its footnote
+		notes    []string // Text of the notes, in the order of the page
+	}{
+		{"inline sidenote", "Text^[first] and {sidenote see {footnote: inner note}} then [^b].\n\n[^b]: third\n",
+			`see <sup class="footnote-ref" id="fnref:2"><a href="#fn:2">2</a></sup></aside>`, []string{"first", "inner note", "third"}},
+		{"block sidenote", "Text^[first].\n\n{sidenote}\nBlock with {footnote: second}.\n{/sidenote}\n\nMore^[third].\n",
+			`with <sup class="footnote-ref" id="fnref:2"><a href="#fn:2">2</a></sup>.</p></aside>`, []string{"first", "second", "third"}},
+	}
+
+	refRegex := regexp.MustCompile(`href="#fn:(\d+)"`)
+	noteRegex := regexp.MustCompile(`<li id="fn:(\d+)">([^<]*) <a`)
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			page := renderMarkdown(src.Document{Body: test.md, BodyLine: 1}, "public/test.md").Content
+
+			// Inline sidenotes follow their paragraph, but are numbered where their marker is
+			var refs []string
+			for _, match := range refRegex.FindAllStringSubmatch(page, -1) {
+				refs = append(refs, match[1])
+			}
+			slices.Sort(refs)
+			if !slices.Equal(refs, []string{"1", "2", "3"}) || !strings.Contains(page, test.sidenote) {
+				t.Errorf("got references %v, want the sidenote to have footnote 2:\n%s", refs, page)
+			}
+
+			// Every note is in the single list at the end of the page
+			var notes []string
+			for i, match := range noteRegex.FindAllStringSubmatch(page, -1) {
+				if match[1] != strconv.Itoa(i+1) {
+					t.Errorf("note %q is numbered %s, want %d", match[2], match[1], i+1)
+				}
+				notes = append(notes, match[2])
+			}
+			if !slices.Equal(notes, test.notes) || strings.Count(page, `class="footnotes"`) != 1 || strings.Contains(page, "<ol start") {
+				t.Errorf("got notes %q, want %q in a single list:\n%s", notes, test.notes, page)
+			}
+		})
+	}
+}
+
+func TestTocPlacement(t *testing.T) {
+	// {toc} places a table of contents where it is written, with its own depth
+	page := renderMarkdown(src.Document{Body: "Intro\n\n{toc depth=1}\n\n# A\n\n## B\n", BodyLine: 1}, "public/test.md")
+	nav := strings.Index(page.Content, `<nav class="toc">`)
+	if nav < strings.Index(page.Content, "<p>Intro</p>") || nav > strings.Index(page.Content, `<h1 id="a">`) {
+		t.Errorf("table of contents is not where {toc} is:\n%s", page.Content)
+	}
+	if !strings.Contains(page.Content, `href="#a"`) || strings.Contains(page.Content, `href="#b"`) || page.Toc != "" {
+		t.Errorf("got table of contents %q in the page and %q in the sidebar", page.Content, page.Toc)
+	}
+
+	// The toc key puts one in the sidebar, leaving the page as it is
+	fm := src.Frontmatter{Toc: true, TocDepth: 1}
+	page = renderMarkdown(src.Document{Frontmatter: fm, Body: "Intro\n\n# A\n\n## B\n", BodyLine: 1}, "public/test.md")
+	if strings.Contains(page.Content, `<nav class="toc">`) || !strings.Contains(page.Toc, `href="#a"`) || strings.Contains(page.Toc, `href="#b"`) {
+		t.Errorf("got table of contents %q in the page and %q in the sidebar", page.Content, page.Toc)
+	}
+}
+
+func TestWordCount(t *testing.T) {
+	// Code blocks and math are left out, the words of headings, lists and tables are counted
+	md := "# Two words\n\nOne *two* [three](x) `four` $y$ and.\n\n```\nnot counted\n```\n\n$$\nx + y\n$$\n\n- Five\n- six\n\n| Seven | eight |\n|---|---|\n| nine | ten |\n"
+	if words := renderMarkdown(src.Document{Body: md, BodyLine: 1}, "public/test.md").Words; words != 13 {
+		t.Errorf("got %d words, want 13", words)
+	}
+}
+
+func TestArticleHeader(t *testing.T) {
+	created, _ := src.ParseTimestamp("2024-01-02")
+	updated, _ := src.ParseTimestamp("2024-03-04")
+	tests := []struct {
+		name        string
+		fm          src.Frontmatter
+		readingTime int
+		want        []string // Parts of the header
+		missing     []string // Parts left out of the header
+	}{
+		{"everything", src.Frontmatter{Title: "Title", Author: "Ada", Created: created, Updated: updated}, 3,
+			[]string{"Title", "By Ada", `Published <time datetime="2024-01-02">January 2, 2024</time>`,
+				`Updated <time datetime="2024-03-04">March 4, 2024</time>`, "3 min read"}, nil},
+		{"title only", src.Frontmatter{Title: "Title"}, 0, []string{"Title"}, []string{"By ", "Published", "Updated", "min read", "Source"}},
+		{"updated when published", src.Frontmatter{Created: created, Updated: created}, 1, []string{"Published", "1 min read"}, []string{"Updated"}},
+		{"nothing", src.Frontmatter{}, 0, nil, []string{"<header"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var sb strings.Builder
+			if err := templates.ArticleHeader(test.fm, true, test.readingTime).Render(context.Background(), &sb); err != nil {
+				t.Fatal(err)
+			}
+			for _, want := range test.want {
+				if !strings.Contains(sb.String(), want) {
+					t.Errorf("header is missing %s:\n%s", want, sb.String())
+				}
+			}
+			for _, missing := range test.missing {
+				if strings.Contains(sb.String(), missing) {
+					t.Errorf("header has %s:\n%s", missing, sb.String())
+				}
+			}
+		})
+	}
+}
+
+func TestFrontmatterParams(t *testing.T) {
+	testSite(t, map[string]string{
+		"projects/demo.md": "---\ndesc: A demo\nstatus: active\nstack: [go, templ]\nrepo: https://example.com/demo\ncover: /static/demo.png\n---\n# Demo\n",
+		"plain.md":         "# Plain\n",
+	})
+	if err := os.MkdirAll("static", 0755); err != nil {
+		t.Fatal(err)
+	}
+	router := newRouter()
+
+	// Cards show the cover, status and stack of the pages that set them
+	_, body := get(router, "/articles")
+	cards := make(map[string]string) // By the page they link
+	pageRegex := regexp.MustCompile(`href="/page/([^"]+)"`)
+	for _, card := range strings.Split(body[strings.Index(body, "<main"):], `<div class="card `)[1:] {
+		if match := pageRegex.FindStringSubmatch(card); match != nil {
+			cards[match[1]] = card
+		}
+	}
+	for _, want := range []string{`<img src="/static/demo.png"`, `badge-outline badge-sm">active</span>`, ">go</span>", ">templ</span>", "A demo"} {
+		if !strings.Contains(cards["projects/demo"], want) {
+			t.Errorf("card is missing %s:\n%s", want, cards["projects/demo"])
+		}
+	}
+	if plain, ok := cards["plain"]; !ok || strings.Contains(plain, "<img") || strings.Contains(plain, "badge-sm") {
+		t.Errorf("got card %q for a page without params", plain)
+	}
+
+	// The header links the source repository
+	if _, body := get(router, "/page/projects/demo"); !strings.Contains(body, `<a class="link" href="https://example.com/demo">Source</a>`) {
+		t.Errorf("page does not link its repository:\n%s", body)
+	}
+	if _, body := get(router, "/page/plain"); strings.Contains(body, ">Source</a>") {
+		t.Errorf("page without a repository links one:\n%s", body)
+	}
+}
+
+// testSite runs the test in a site of its own, with the given files under public/ and the default config
+func testSite(t *testing.T, pages map[string]string) {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range pages {
+		writePage(t, filepath.Join(dir, "public", name), content)
+	}
+	t.Chdir(dir)
+
+	oldSite, oldCache, oldLinks, oldFrontmatters := site, pageCache, links, frontmatters
+	site, pageCache, links = src.DefaultConfig(), cache.New[renderedPage]("", ""), &linkGraph{}
+	frontmatters = &frontmatterCache{entries: make(map[string]cachedFrontmatter)}
+	t.Cleanup(func() { site, pageCache, links, frontmatters = oldSite, oldCache, oldLinks, oldFrontmatters })
+}
+
+func writePage(t *testing.T, path string, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// get requests route from router, returning the status and body
+func get(router http.Handler, route string) (int, string) {
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, route, nil))
+	return rec.Code, rec.Body.String()
+}
+
+func TestWikiLinkResolvesOncePageExists(t *testing.T) {
+	testSite(t, map[string]string{"a.md": "See [[newpage]].\n"})
+	router := newRouter()
+
+	if _, body := get(router, "/page/a"); !strings.Contains(body, "wikilink-missing") {
+		t.Fatalf("expected a missing wiki link:\n%s", body)
+	}
+
+	writePage(t, filepath.Join("public", "newpage.md"), "# New page\n")
+	if _, body := get(router, "/page/a"); strings.Contains(body, "wikilink-missing") || !strings.Contains(body, `href="/page/newpage"`) {
+		t.Fatalf("expected the wiki link to resolve once the page exists:\n%s", body)
+	}
+
+	writePage(t, filepath.Join("public", "newpage.md"), "---\ndraft: true\n---\n# New page\n")
+	if _, body := get(router, "/page/a"); !strings.Contains(body, "wikilink-missing") {
+		t.Fatalf("expected the wiki link to break once the page is a draft:\n%s", body)
+	}
+}
+
+func TestPageFrontmatterIsCached(t *testing.T) {
+	testSite(t, map[string]string{"a.md": "---\ntitle: One\n---\n"})
+	path := filepath.Join("public", "a.md")
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if title := pageTitle(models.File{Path: "a"}); title != "One" {
+		t.Fatalf("got title %q", title)
+	}
+
+	// A file of the same size and modification time is not read again
+	writePage(t, path, "---\ntitle: Two\n---\n")
+	if err := os.Chtimes(path, info.ModTime(), info.ModTime()); err != nil {
+		t.Fatal(err)
+	}
+	if title := pageTitle(models.File{Path: "a"}); title != "One" {
+		t.Fatalf("got title %q, want the cached one", title)
+	}
+
+	later := info.ModTime().Add(time.Second)
+	if err := os.Chtimes(path, later, later); err != nil {
+		t.Fatal(err)
+	}
+	if title := pageTitle(models.File{Path: "a"}); title != "Two" {
+		t.Fatalf("got title %q once the file changed", title)
+	}
+}
+
+func TestBuildSite(t *testing.T) {
+	testSite(t, map[string]string{"a/b.md": "---\ntags: [Go]\n---\n# B\n\nSee [c](c).\n", "a/c.md": "# C\n"})
+	if err := os.MkdirAll("static", 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, out := range []string{".", "..", "public", "static", filepath.Join("public", "out")} {
+		if err := buildSite(newRouter(), out); err == nil {
+			t.Errorf("built into %s", out)
+		}
+	}
+	if _, err := os.Stat(filepath.Join("public", "a", "b.md")); err != nil {
+		t.Fatal("refused build removed content:", err)
+	}
+
+	// Pages are written where a static host serves them at their route, so a/b links to a/c as it does on the server.
+	// Each is what the server sends for its route, byte for byte.
+	router := newRouter()
+	if err := buildSite(router, "dist"); err != nil {
+		t.Fatal(err)
+	}
+	routes := map[string]string{
+		"index.html":      "/",
+		"articles.html":   "/articles",
+		"page/a/b.html":   "/page/a/b",
+		"page/a/c.html":   "/page/a/c",
+		"tags.html":       "/tags",
+		"tags/go.html":    "/tags/go",
+		"categories.html": "/categories",
+	}
+	for path, route := range routes {
+		built, err := os.ReadFile(filepath.Join("dist", filepath.FromSlash(path)))
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if status, served := get(router, route); status != http.StatusOK || string(built) != served {
+			t.Errorf("%s differs from %s, which has status %d", path, route, status)
+		}
+	}
+}
+
+func TestDraftsOnlyInPreview(t *testing.T) {
+	testSite(t, map[string]string{
+		"public.md": "# Public\n",
+		"draft.md":  "---\ndraft: true\n---\n# Draft\n",
+	})
+	if err := os.MkdirAll("static", 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, preview := range []bool{false, true} {
+		site.Preview = preview
+		router := newRouter()
+
+		status, _ := get(router, "/page/draft")
+		if want := map[bool]int{false: http.StatusNotFound, true: http.StatusOK}[preview]; status != want {
+			t.Errorf("preview %v: got status %d for the draft, want %d", preview, status, want)
+		}
+
+		folder, err := siteTree("")
+		if err != nil {
+			t.Fatal(err)
+		}
+		var paths []string
+		for _, file := range folder.AllFiles() {
+			paths = append(paths, file.Path)
+		}
+		if slices.Contains(paths, "draft") != preview {
+			t.Errorf("preview %v: got site tree %v", preview, paths)
+		}
+
+		// Listed with a badge while previewing, and not at all otherwise
+		for _, route := range []string{"/articles", "/page/public"} {
+			_, body := get(router, route)
+			if strings.Contains(body, `href="/page/draft"`) != preview || strings.Contains(body, ">Draft</span>") != preview {
+				t.Errorf("preview %v: %s lists the draft wrongly:\n%s", preview, route, body)
+			}
+		}
+
+		if err := buildSite(router, "dist"); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := os.Stat(filepath.Join("dist", "page", "draft.html")); (err == nil) != preview {
+			t.Errorf("preview %v: build wrote the draft: %v", preview, err == nil)
+		}
+	}
+}
+
+func TestNextScheduledChange(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name  string
+		pages map[string]string
+		want  string // Next change, empty if there is none
+	}{
+		{"unset", map[string]string{"a.md": "# A\n"}, ""},
+		{"past", map[string]string{"a.md": "---\npublish_at: 2025-01-01T00:00:00Z\nexpire_at: 2025-05-01T00:00:00Z\n---\n"}, ""},
+		{"publish in the future", map[string]string{"a.md": "---\npublish_at: 2025-07-01T00:00:00Z\n---\n"},
+			"public/a.md is published at 2025-07-01T00:00:00Z"},
+		{"expiry in the future", map[string]string{"a.md": "---\npublish_at: 2025-01-01T00:00:00Z\nexpire_at: 2025-07-01T00:00:00Z\n---\n"},
+			"public/a.md expires at 2025-07-01T00:00:00Z"},
+		{"first of several", map[string]string{
+			"a.md":     "---\npublish_at: 2025-08-01T00:00:00Z\nexpire_at: 2025-09-01T00:00:00Z\n---\n",
+			"b/c.md":   "---\nexpire_at: 2025-07-01T00:00:00Z\n---\n",
+			"draft.md": "---\ndraft: true\npublish_at: 2025-06-02T00:00:00Z\n---\n", // Drafts are never published
+		}, "public/b/c.md expires at 2025-07-01T00:00:00Z"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			testSite(t, test.pages)
+			next, ok, err := nextScheduledChange(now)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := map[bool]string{true: next.String()}[ok]; got != test.want {
+				t.Errorf("got next change %q, want %q", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,148 @@
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// RenderFunc produces the rendered output for a cache miss
+type RenderFunc[T any] func() (T, error)
+
+type entry[T any] struct {
+	hash string
+	data T
+}
+
+// call tracks a render that is currently in flight, so concurrent
+// requests for the same page wait for it instead of rendering again
+type call[T any] struct {
+	wg   sync.WaitGroup
+	data T
+	err  error
+}
+
+// Cache holds rendered pages keyed by name and the hash of their source.
+// A changed source produces a new hash, which invalidates the old entry.
+// Values persisted to disk are stored as JSON.
+type Cache[T any] struct {
+	mu      sync.Mutex
+	entries map[string]entry[T]
+	calls   map[string]*call[T]
+	dir     string // Optional directory to persist rendered output to, empty for memory only
+	version string // Identifies the renderer and its configuration, so persisted output from another version is not used
+}
+
+// New returns a cache persisting to dir, if it is not empty. Output rendered with another version is rendered again.
+func New[T any](dir string, version string) *Cache[T] {
+	return &Cache[T]{
+		entries: make(map[string]entry[T]),
+		calls:   make(map[string]*call[T]),
+		dir:     dir,
+		version: version,
+	}
+}
+
+// Hash returns the hex encoded SHA-256 of all given parts
+func Hash(parts ...[]byte) string {
+	h := sha256.New()
+	for _, part := range parts {
+		h.Write(part)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Get returns the cached output for key if it was rendered from the same source,
+// otherwise it calls render once and stores the result.
+func (c *Cache[T]) Get(key string, source []byte, render RenderFunc[T]) (T, error) {
+	hash := Hash([]byte(c.version), []byte{0}, []byte(key), []byte{0}, source)
+
+	c.mu.Lock()
+	if e, ok := c.entries[key]; ok && e.hash == hash {
+		c.mu.Unlock()
+		return e.data, nil
+	}
+	if cl, ok := c.calls[hash]; ok {
+		// Someone is already rendering this exact source, wait for them
+		c.mu.Unlock()
+		cl.wg.Wait()
+		return cl.data, cl.err
+	}
+	cl := &call[T]{}
+	cl.wg.Add(1)
+	c.calls[hash] = cl
+	c.mu.Unlock()
+
+	// Waiting requests are released even if render panics, with an error rather than an empty page
+	defer func() {
+		c.mu.Lock()
+		delete(c.calls, hash)
+		c.mu.Unlock()
+		cl.wg.Done()
+	}()
+	cl.err = fmt.Errorf("rendering %s panicked", key)
+
+	cl.data, cl.err = c.load(hash, render)
+
+	if cl.err == nil {
+		c.mu.Lock()
+		if old, ok := c.entries[key]; ok && old.hash != hash {
+			c.remove(old.hash)
+		}
+		c.entries[key] = entry[T]{hash: hash, data: cl.data}
+		c.mu.Unlock()
+	}
+
+	return cl.data, cl.err
+}
+
+// Invalidate drops the entry for key, forcing the next Get to render again
+func (c *Cache[T]) Invalidate(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if e, ok := c.entries[key]; ok {
+		c.remove(e.hash)
+		delete(c.entries, key)
+	}
+}
+
+// load reads a persisted render from disk if available, otherwise renders and persists it
+func (c *Cache[T]) load(hash string, render RenderFunc[T]) (T, error) {
+	var data T
+
+	if c.dir != "" {
+		if raw, err := os.ReadFile(c.path(hash)); err == nil && json.Unmarshal(raw, &data) == nil {
+			return data, nil
+		}
+	}
+
+	data, err := render()
+	if err != nil {
+		return data, err
+	}
+
+	if c.dir != "" {
+		// Failing to persist is not fatal, the page is still cached in memory
+		raw, err := json.Marshal(data)
+		if err == nil && os.MkdirAll(c.dir, 0755) == nil {
+			_ = os.WriteFile(c.path(hash), raw, 0644)
+		}
+	}
+
+	return data, nil
+}
+
+func (c *Cache[T]) remove(hash string) {
+	if c.dir != "" {
+		_ = os.Remove(c.path(hash))
+	}
+}
+
+func (c *Cache[T]) path(hash string) string {
+	return filepath.Join(c.dir, hash+".json")
+}
@@ -0,0 +1,96 @@
+package cache
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestGetRendersOncePerSource(t *testing.T) {
+	c := New[[]byte]("", "")
+	var renders int64
+
+	render := func() ([]byte, error) {
+		atomic.AddInt64(&renders, 1)
+		return []byte("rendered"), nil
+	}
+
+	var wg sync.WaitGroup
+	for range 50 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := c.Get("page", []byte("source"), render); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if renders != 1 {
+		t.Fatalf("expected 1 render, got %d", renders)
+	}
+
+	if _, err := c.Get("page", []byte("changed source"), render); err != nil {
+		t.Fatal(err)
+	}
+	if renders != 2 {
+		t.Fatalf("expected changed source to re-render, got %d renders", renders)
+	}
+}
+
+func TestGetPersistsToDir(t *testing.T) {
+	dir := t.TempDir()
+	render := func() (string, error) { return "rendered", nil }
+
+	if _, err := New[string](dir, "v1").Get("page", []byte("source"), render); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := New[string](dir, "v1").Get("page", []byte("source"), func() (string, error) {
+		t.Fatal("expected page to be loaded from disk")
+		return "", nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data != "rendered" {
+		t.Fatalf("unexpected cached data %q", data)
+	}
+}
+
+func TestGetRendersAgainForAnotherVersion(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := New[string](dir, "v1").Get("page", []byte("source"), func() (string, error) { return "v1", nil }); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := New[string](dir, "v2").Get("page", []byte("source"), func() (string, error) { return "v2", nil })
+	if err != nil || data != "v2" {
+		t.Fatalf("got %q, %v, want output rendered by v2", data, err)
+	}
+}
+
+func TestGetAfterPanic(t *testing.T) {
+	c := New[string]("", "")
+	func() {
+		defer func() { _ = recover() }()
+		_, _ = c.Get("page", []byte("source"), func() (string, error) { panic("render failed") })
+	}()
+
+	done := make(chan error)
+	go func() {
+		_, err := c.Get("page", []byte("source"), func() (string, error) { return "", errors.New("rendered again") })
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err == nil || err.Error() != "rendered again" {
+			t.Fatalf("got %v, want the page to be rendered again", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Get blocked after a render panicked")
+	}
+}
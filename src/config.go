@@ -0,0 +1,80 @@
+package src
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"time"
+
+	"github.com/alecthomas/chroma/v2/styles"
+	"github.com/goccy/go-yaml"
+)
+
+// Config is the site wide configuration, read from site.yaml
+type Config struct {
+	CodeStyle     string `yaml:"code_style"`      // Chroma style of code blocks
+	CodeStyleDark string `yaml:"code_style_dark"` // Chroma style of code blocks when the reader prefers a dark color scheme
+	WPM           int    `yaml:"wpm"`             // Reading speed in words per minute, for pages without their own
+	Preview       bool   `yaml:"preview"`         // Show drafts and unpublished pages, marked as such, for writing rather than publishing
+	Timezone      string `yaml:"timezone"`        // Timezone of publish_at and expire_at without a zone, like Europe/Stockholm
+
+	location *time.Location
+}
+
+// DefaultConfig is the configuration of a site without a config file, and the defaults of settings missing from one
+func DefaultConfig() Config {
+	return Config{
+		CodeStyle:     "catppuccin-latte",
+		CodeStyleDark: "catppuccin-frappe",
+		WPM:           200,
+		Timezone:      "UTC",
+		location:      time.UTC,
+	}
+}
+
+// Location returns the site's timezone
+func (c Config) Location() *time.Location {
+	if c.location == nil {
+		return time.UTC
+	}
+	return c.location
+}
+
+// LoadConfig reads the site configuration at path, falling back to the defaults if there is no such file
+func LoadConfig(path string) (Config, error) {
+	config := DefaultConfig()
+
+	content, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return config, nil
+	} else if err != nil {
+		return config, err
+	}
+
+	if err := yaml.Unmarshal(content, &config); err != nil {
+		return config, fmt.Errorf("%s: %w", path, err)
+	}
+
+	for _, style := range []string{config.CodeStyle, config.CodeStyleDark} {
+		if !IsCodeStyle(style) {
+			return config, fmt.Errorf("%s: unknown code style %q", path, style)
+		}
+	}
+
+	if config.location, err = time.LoadLocation(config.Timezone); err != nil {
+		return config, fmt.Errorf("%s: timezone: %w", path, err)
+	}
+
+	if config.WPM <= 0 {
+		return config, fmt.Errorf("%s: wpm must be positive, not %d", path, config.WPM)
+	}
+
+	return config, nil
+}
+
+// IsCodeStyle reports whether name is a registered chroma style
+func IsCodeStyle(name string) bool {
+	_, ok := styles.Registry[name]
+	return ok
+}
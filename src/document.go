@@ -0,0 +1,208 @@
+package src
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"reflect"
+	"slices"
+	"strings"
+
+	"website/src/models"
+)
+
+// Document is a page of the site, its frontmatter and its Markdown body
+type Document struct {
+	Frontmatter Frontmatter
+	Body        string
+	BodyLine    int // Line of the file the body starts on, counting from 1
+
+	// Problems found in the frontmatter, as *FrontmatterError. The keys they are about keep their zero values,
+	// except that a page is a draft when it can not be told whether it is published: its frontmatter can not
+	// be read, or its draft, publish_at or expire_at is invalid. It is hidden rather than published by mistake.
+	Problems []error
+}
+
+// FrontmatterError is a problem with the frontmatter of a page, at a line of its file
+type FrontmatterError struct {
+	Path string
+	Line int
+	Msg  string
+}
+
+func (e *FrontmatterError) Error() string {
+	return fmt.Sprintf("%s:%d: %s", e.Path, e.Line, e.Msg)
+}
+
+// LoadDocument reads and parses the page at path. The error is only for a file that can not be read,
+// problems with its frontmatter are in the document.
+func LoadDocument(path string) (Document, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return Document{}, err
+	}
+	return ParseDocument(path, content), nil
+}
+
+// ParseDocument splits the content of the page at path into its frontmatter and body, in a single pass.
+// Frontmatter is only recognised at the very start of the page, in one of the FrontmatterDecoders formats.
+func ParseDocument(path string, content []byte) Document {
+	doc := Document{Body: string(content), BodyLine: 1}
+
+	content = bytes.TrimPrefix(content, []byte("\ufeff"))
+	for _, decoder := range FrontmatterDecoders {
+		block, ok, err := decoder.Split(content)
+		if !ok {
+			continue
+		}
+		if err != nil {
+			doc.Frontmatter.Draft = true
+			doc.Problems = []error{frontmatterProblem(path, 1, err)}
+			return doc
+		}
+
+		doc.Body = string(block.Body)
+		doc.BodyLine = 1 + bytes.Count(content[:len(content)-len(block.Body)], []byte("\n"))
+		doc.Frontmatter, doc.Problems = decodeFrontmatter(path, decoder, block)
+		return doc
+	}
+	return doc
+}
+
+// frontmatterProblem places err in the file at path. A *FrontmatterError has a line counted from line of the file,
+// other errors are on that line.
+func frontmatterProblem(path string, line int, err error) *FrontmatterError {
+	var located *FrontmatterError
+	if errors.As(err, &located) {
+		return &FrontmatterError{Path: path, Line: line + located.Line - 1, Msg: located.Msg}
+	}
+	return &FrontmatterError{Path: path, Line: line, Msg: err.Error()}
+}
+
+// frontmatterFields are the fields of Frontmatter by their keys
+var frontmatterFields = func() map[string]int {
+	fields := make(map[string]int)
+	t := reflect.TypeFor[Frontmatter]()
+	for i := range t.NumField() {
+		if key := strings.Split(t.Field(i).Tag.Get("yaml"), ",")[0]; key != "" && key != "-" {
+			fields[key] = i
+		}
+	}
+	return fields
+}()
+
+// publishingKeys decide whether a page is published, a page with an invalid one is a draft
+var publishingKeys = map[string]bool{"draft": true, "publish_at": true, "expire_at": true}
+
+// decodeFrontmatter decodes the frontmatter of the file at path key by key,
+// so every misspelt key and invalid value is found and reported at its line. Other keys go in Params.
+func decodeFrontmatter(path string, decoder FrontmatterDecoder, block FrontmatterBlock) (Frontmatter, []error) {
+	var fm Frontmatter
+	var problems []error
+	report := func(line int, format string, args ...any) {
+		problems = append(problems, &FrontmatterError{Path: path, Line: block.Line + line - 1, Msg: fmt.Sprintf(format, args...)})
+	}
+
+	keys, err := decoder.Decode(block.Content)
+	if err != nil {
+		fm.Draft = true
+		return fm, []error{frontmatterProblem(path, block.Line, err)}
+	}
+
+	value := reflect.ValueOf(&fm).Elem()
+	lines := make(map[string]int)
+	unpublishable := false // A key deciding whether the page is published is invalid
+	for _, key := range keys {
+		lines[key.Name] = key.Line
+
+		index, ok := frontmatterFields[key.Name]
+		if !ok {
+			// A misspelt key would otherwise quietly become a custom one
+			if known := misspeltKey(key.Name); known != "" {
+				report(key.Line, "unknown key %q, did you mean %q?", key.Name, known)
+				unpublishable = unpublishable || publishingKeys[known]
+				continue
+			}
+
+			var param any
+			if err := key.Decode(&param); err != nil {
+				report(key.Line, "%s: %v", key.Name, err)
+				continue
+			}
+			if fm.Params == nil {
+				fm.Params = make(models.Params)
+			}
+			if extra, ok := param.(map[string]any); ok && key.Name == "extra" {
+				maps.Copy(fm.Params, extra)
+			} else {
+				fm.Params[key.Name] = param
+			}
+			continue
+		}
+
+		field := value.Field(index)
+		if err := key.Decode(field.Addr().Interface()); err != nil {
+			field.SetZero()
+			report(key.Line, "%s: %v", key.Name, err)
+			unpublishable = unpublishable || publishingKeys[key.Name]
+			continue
+		}
+
+		if validate, ok := validateFrontmatter[key.Name]; ok {
+			if err := validate(&fm); err != nil {
+				report(key.Line, "%s: %v", key.Name, err)
+			}
+		}
+	}
+
+	if !fm.PublishAt.IsZero() && !fm.ExpireAt.IsZero() && !fm.ExpireAt.After(fm.PublishAt.Time) {
+		report(lines["expire_at"], "expire_at: must be after publish_at")
+		unpublishable = true
+	}
+	if unpublishable {
+		fm.Draft = true
+	}
+
+	return fm, problems
+}
+
+// misspeltKey returns the key of Frontmatter that key is a misspelling of, like Title or titel for title,
+// or the empty string if it is not close to one
+func misspeltKey(key string) string {
+	normalise := func(s string) string {
+		return strings.NewReplacer("_", "", "-", "").Replace(strings.ToLower(s))
+	}
+	for _, known := range slices.Sorted(maps.Keys(frontmatterFields)) {
+		if normalise(key) == normalise(known) || (len(known) >= 4 && editDistance(key, known) <= 1) {
+			return known
+		}
+	}
+	return ""
+}
+
+// editDistance is the number of insertions, deletions, substitutions and swaps of adjacent letters that turn a into b
+func editDistance(a, b string) int {
+	d := make([][]int, len(a)+1)
+	for i := range d {
+		d[i] = make([]int, len(b)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			d[i][j] = min(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				d[i][j] = min(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+	return d[len(a)][len(b)]
+}
@@ -0,0 +1,131 @@
+package src
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseDocument(t *testing.T) {
+	source := "---\ntitle: Test\ncreated: 2025-07-15\nwpm: lots\nlayout: wide\ntitel: Typo\n---\n\n# Body\n\n---\n\nMore\n"
+	doc := ParseDocument("public/test.md", []byte(source))
+
+	if doc.Frontmatter.Title != "Test" || doc.Frontmatter.Layout != LayoutWide || doc.Frontmatter.Created.Format(dateLayout) != "2025-07-15" {
+		t.Errorf("got frontmatter %+v", doc.Frontmatter)
+	}
+	if doc.BodyLine != 8 || !strings.HasPrefix(doc.Body, "\n# Body\n\n---\n") {
+		t.Errorf("got body at line %d: %q", doc.BodyLine, doc.Body)
+	}
+
+	var problems []string
+	for _, problem := range doc.Problems {
+		problems = append(problems, strings.SplitN(problem.Error(), ": ", 2)[0])
+	}
+	if want := []string{"public/test.md:4", "public/test.md:6"}; !reflect.DeepEqual(problems, want) {
+		t.Errorf("got problems %v, want them at %v", doc.Problems, want)
+	}
+}
+
+func TestParseDocumentWithoutFrontmatter(t *testing.T) {
+	source := "# Title\n\n---\ntitle: not frontmatter\n---\n"
+	doc := ParseDocument("public/test.md", []byte(source))
+	if doc.Body != source || doc.BodyLine != 1 || doc.Frontmatter.Title != "" || len(doc.Problems) != 0 {
+		t.Errorf("got %+v", doc)
+	}
+}
+
+func TestParseDocumentInvalidSchedule(t *testing.T) {
+	doc := ParseDocument("public/test.md", []byte("---\npublish_at: soon\n---\n"))
+	if !doc.Frontmatter.Draft || len(doc.Problems) != 1 {
+		t.Errorf("got %+v", doc)
+	}
+}
+
+func TestParseDocumentInvalidDraft(t *testing.T) {
+	sources := []string{
+		"---\ndraft: yes\n---\n",
+		"---\ndraft: \"true\"\n---\n",
+		"---\nDraft: true\n---\n",
+		"---\ndraft: [true\n---\n",
+		"---\ndraft: true\n",
+		"+++\ndraft = \"false\"\n+++\n",
+	}
+	for _, source := range sources {
+		doc := ParseDocument("public/test.md", []byte(source))
+		if !doc.Frontmatter.Draft || len(doc.Problems) != 1 {
+			t.Errorf("%q: got draft %v, problems %v", source, doc.Frontmatter.Draft, doc.Problems)
+		}
+	}
+}
+
+func TestParseDocumentFormats(t *testing.T) {
+	sources := map[string]string{
+		"yaml": "---\ntitle: Test\ncreated: 2025-07-15\nlayout: wide\ntoc: true\n---\n# Body\n",
+		"toml": "+++\ntitle = \"Test\"\ncreated = 2025-07-15\nlayout = \"wide\"\ntoc = true\n+++\n# Body\n",
+		"json": "{\n  \"title\": \"Test\",\n  \"created\": \"2025-07-15\",\n  \"layout\": \"wide\",\n  \"toc\": true\n}\n# Body\n",
+	}
+	for format, source := range sources {
+		doc := ParseDocument("public/test.md", []byte(source))
+		fm := doc.Frontmatter
+		if fm.Title != "Test" || fm.Created.Format(dateLayout) != "2025-07-15" || fm.Layout != LayoutWide || !fm.Toc || len(doc.Problems) != 0 {
+			t.Errorf("%s: got %+v, problems %v", format, fm, doc.Problems)
+		}
+		if doc.Body != "# Body\n" || doc.BodyLine != 7 {
+			t.Errorf("%s: got body at line %d: %q", format, doc.BodyLine, doc.Body)
+		}
+	}
+}
+
+func TestParseDocumentFormatProblems(t *testing.T) {
+	sources := map[string]string{
+		"toml": "+++\ntitle = \"Test\"\nwpm = \"lots\"\n\n[Author]\nname = \"someone\"\n+++\n",
+		"json": "{\"title\": \"Test\",\n\n \"wpm\": \"lots\",\n\n \"Author\": {\"name\": \"someone\"}}\n",
+	}
+	for format, source := range sources {
+		doc := ParseDocument("public/test.md", []byte(source))
+		var problems []string
+		for _, problem := range doc.Problems {
+			problems = append(problems, strings.SplitN(problem.Error(), ": ", 2)[0])
+		}
+		if want := []string{"public/test.md:3", "public/test.md:5"}; doc.Frontmatter.Title != "Test" || !reflect.DeepEqual(problems, want) {
+			t.Errorf("%s: got problems %v, want them at %v", format, doc.Problems, want)
+		}
+	}
+
+	doc := ParseDocument("public/test.md", []byte("{sidenote} is not frontmatter\n"))
+	if doc.BodyLine != 1 || len(doc.Problems) != 0 {
+		t.Errorf("got %+v", doc)
+	}
+}
+
+func TestParseDocumentParams(t *testing.T) {
+	sources := map[string]string{
+		"yaml": "---\ntitle: Test\nstatus: active\nstars: 12\nstack: [go, templ]\nextra:\n  cover: cover.png\n---\n",
+		"toml": "+++\ntitle = \"Test\"\nstatus = \"active\"\nstars = 12\nstack = [\"go\", \"templ\"]\n\n[extra]\ncover = \"cover.png\"\n+++\n",
+		"json": "{\"title\": \"Test\", \"status\": \"active\", \"stars\": 12, \"stack\": [\"go\", \"templ\"], \"extra\": {\"cover\": \"cover.png\"}}\n",
+	}
+	for format, source := range sources {
+		doc := ParseDocument("public/test.md", []byte(source))
+		params := doc.Frontmatter.Params
+		if len(doc.Problems) != 0 || params.String("status") != "active" || params.Int("stars") != 12 || params.String("stars") != "12" ||
+			!reflect.DeepEqual(params.Strings("stack"), []string{"go", "templ"}) || params.String("cover") != "cover.png" || params.Has("extra") {
+			t.Errorf("%s: got params %#v, problems %v", format, params, doc.Problems)
+		}
+		if params.String("missing") != "" || params.Bool("status") || params.Strings("missing") != nil {
+			t.Errorf("%s: got values for missing keys", format)
+		}
+	}
+}
+
+func TestParseDocumentTerms(t *testing.T) {
+	doc := ParseDocument("public/test.md", []byte("---\ntags: [Go, \" static sites \", go, Static-Sites]\ncategories: Projects\n---\n"))
+	if fm := doc.Frontmatter; !reflect.DeepEqual(fm.Tags, Terms{"Go", "static sites"}) || !reflect.DeepEqual(fm.Categories, Terms{"Projects"}) || len(doc.Problems) != 0 {
+		t.Errorf("got tags %q, categories %q, problems %v", fm.Tags, fm.Categories, doc.Problems)
+	}
+
+	// Only the invalid term is dropped
+	doc = ParseDocument("public/test.md", []byte("---\ntags: [go, \"--\"]\n---\n"))
+	if !reflect.DeepEqual(doc.Frontmatter.Tags, Terms{"go"}) || len(doc.Problems) != 1 {
+		t.Errorf("got tags %q, problems %v", doc.Frontmatter.Tags, doc.Problems)
+	}
+}
@@ -1,96 +1,405 @@
 package src
 
 import (
-	"bufio"
+	"bytes"
+	"encoding/json"
 	"errors"
-	"os"
+	"fmt"
+	"slices"
+	"strconv"
 	"strings"
 
 	"github.com/goccy/go-yaml"
+	"github.com/goccy/go-yaml/ast"
+	"github.com/goccy/go-yaml/parser"
+	"github.com/pelletier/go-toml/v2"
+	"github.com/pelletier/go-toml/v2/unstable"
+	"website/src/models"
+	"website/src/slug"
 )
 
 type Frontmatter struct {
-	Title   string `yaml:"title"`
-	Desc    string `yaml:"desc"`
-	WPM     int    `yaml:"wpm"`
-	Draft   bool   `yaml:"draft"`
-	Created string `yaml:"created"`
-	Updated string `yaml:"updated"`
-	Author  string `yaml:"author"`
+	Title   string    `yaml:"title"`
+	Desc    string    `yaml:"desc"`
+	WPM     int       `yaml:"wpm"`
+	Draft   bool      `yaml:"draft"`
+	Created Timestamp `yaml:"created"`
+	Updated Timestamp `yaml:"updated"`
+	Author  string    `yaml:"author"`
+	Layout  Layout    `yaml:"layout"`
+
+	Toc      bool `yaml:"toc"`       // Show a table of contents in the page sidebar
+	TocDepth int  `yaml:"toc_depth"` // Number of heading levels in the table of contents
+
+	PublishAt Timestamp `yaml:"publish_at"` // Time the page is published at, in the site's timezone unless it has a zone
+	ExpireAt  Timestamp `yaml:"expire_at"`  // Time the page is taken down at
+
+	CodeStyle     string `yaml:"code_style"`      // Chroma style of code blocks, overriding the site config
+	CodeStyleDark string `yaml:"code_style_dark"` // Chroma style of code blocks in dark mode, overriding the site config
+
+	Tags       Terms `yaml:"tags"`       // Listed at /tags
+	Categories Terms `yaml:"categories"` // Listed at /categories
+
+	// Every other key, for templates to read. The keys of a Zola style extra table are custom keys as well.
+	Params models.Params `yaml:"-"`
 }
 
-func ScanFrontmatter(path string) (string, error) {
-	file, err := os.Open(path)
-	if err != nil {
-		return "", err
+// Layout is how the content of a page is laid out
+type Layout string
+
+const (
+	LayoutArticle Layout = "article" // Text at a comfortable reading width, the default
+	LayoutWide    Layout = "wide"    // Content across the full width of the page, for wide tables and charts
+)
+
+func (l *Layout) UnmarshalYAML(b []byte) error {
+	var s string
+	if err := yaml.Unmarshal(b, &s); err != nil {
+		return err
 	}
-	defer file.Close()
+	switch layout := Layout(s); layout {
+	case LayoutArticle, LayoutWide:
+		*l = layout
+		return nil
+	}
+	return fmt.Errorf("unknown layout %q, expected %q or %q", s, LayoutArticle, LayoutWide)
+}
+
+// Terms are the tags or categories of a page, written as a list or as a single term
+type Terms []string
+
+func (t *Terms) UnmarshalYAML(b []byte) error {
+	var list []string
+	if err := yaml.Unmarshal(b, &list); err != nil {
+		var term string
+		if yaml.Unmarshal(b, &term) != nil {
+			return err
+		}
+		list = []string{term}
+	}
+
+	// Terms written differently but with the same slug are one term, written as it is first
+	*t = nil
+	slugs := make(map[string]bool)
+	for _, term := range list {
+		term = strings.TrimSpace(term)
+		id := slug.Make(term)
+		if term == "" || slugs[id] && id != "" {
+			continue
+		}
+		slugs[id] = true
+		*t = append(*t, term)
+	}
+	return nil
+}
+
+// validateFrontmatter checks the value of a key of the frontmatter once it is decoded,
+// resetting what is invalid about it
+var validateFrontmatter = map[string]func(fm *Frontmatter) error{
+	"wpm": func(fm *Frontmatter) error {
+		if fm.WPM < 0 {
+			fm.WPM = 0
+			return fmt.Errorf("must not be negative")
+		}
+		return nil
+	},
+	"toc_depth": func(fm *Frontmatter) error {
+		if fm.TocDepth < 0 || fm.TocDepth > 6 {
+			fm.TocDepth = 0
+			return fmt.Errorf("must be between 1 and 6, or 0 for the default")
+		}
+		return nil
+	},
+	"code_style": func(fm *Frontmatter) error {
+		if style := fm.CodeStyle; !IsCodeStyle(style) {
+			fm.CodeStyle = ""
+			return fmt.Errorf("unknown code style %q", style)
+		}
+		return nil
+	},
+	"code_style_dark": func(fm *Frontmatter) error {
+		if style := fm.CodeStyleDark; !IsCodeStyle(style) {
+			fm.CodeStyleDark = ""
+			return fmt.Errorf("unknown code style %q", style)
+		}
+		return nil
+	},
+	"tags": func(fm *Frontmatter) error {
+		return validateTerms(&fm.Tags)
+	},
+	"categories": func(fm *Frontmatter) error {
+		return validateTerms(&fm.Categories)
+	},
+}
 
-	scanner := bufio.NewScanner(file)
-	var frontmatterLines []string
+// FrontmatterDecoder reads a format of frontmatter
+type FrontmatterDecoder interface {
+	// Split finds frontmatter in this format at the very start of content. ok is false if there is none,
+	// the error is for frontmatter that is opened but can not be told apart from the body.
+	Split(content []byte) (block FrontmatterBlock, ok bool, err error)
 
-	inFrontmatter := false
+	// Decode parses the frontmatter of a block into its top-level keys, in the order they are written in.
+	// Errors about a line are *FrontmatterError, with lines counted from the start of the block.
+	Decode(content []byte) ([]FrontmatterKey, error)
+}
 
-	for scanner.Scan() {
-		line := scanner.Text()
+// FrontmatterBlock is the frontmatter at the start of a page, split from its body
+type FrontmatterBlock struct {
+	Content []byte // The frontmatter, as it is passed to Decode
+	Line    int    // Line of the page Content starts on, counting from 1
+	Body    []byte // The rest of the page
+}
+
+// FrontmatterKey is a key of decoded frontmatter
+type FrontmatterKey struct {
+	Name   string
+	Line   int                    // Line of the block the key is on
+	Decode func(target any) error // Decodes the value into the pointer target, which may implement yaml.BytesUnmarshaler
+}
 
-		if strings.TrimSpace(line) == "---" {
-			if inFrontmatter {
-				inFrontmatter = false
-				break // End of frontmatter
+// FrontmatterDecoders are the formats of frontmatter pages may start with, tried in order
+var FrontmatterDecoders = []FrontmatterDecoder{YAMLFrontmatter{}, TOMLFrontmatter{}, JSONFrontmatter{}}
+
+// splitFenced splits frontmatter between an opening fence on the first line and a closing fence on its own line
+func splitFenced(content []byte, open string, close ...string) (FrontmatterBlock, bool, error) {
+	first, rest, _ := bytes.Cut(content, []byte("\n"))
+	if string(bytes.TrimRight(first, " \t\r")) != open {
+		return FrontmatterBlock{}, false, nil
+	}
+
+	block := FrontmatterBlock{Content: rest, Line: 2}
+	for offset := 0; offset < len(rest); {
+		line, _, _ := bytes.Cut(rest[offset:], []byte("\n"))
+		end := min(offset+len(line)+1, len(rest))
+		for _, fence := range close {
+			if string(bytes.TrimRight(line, " \t\r")) == fence {
+				block.Content = rest[:offset]
+				block.Body = rest[end:]
+				return block, true, nil
 			}
-			inFrontmatter = true
-			continue
 		}
+		offset = end
+	}
+	return block, true, fmt.Errorf("frontmatter is not closed with %s", close[0])
+}
+
+// yamlMessage is the message of a YAML error without the source it quotes
+func yamlMessage(err error) error {
+	var yamlErr yaml.Error
+	if errors.As(err, &yamlErr) {
+		return errors.New(yamlErr.GetMessage())
+	}
+	return err
+}
+
+// decodeValue decodes a value written as JSON into target the way YAML values are decoded,
+// so every format shares the YAML decoding of Frontmatter and its fields
+func decodeValue(value any, target any) error {
+	b, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return yamlMessage(yaml.Unmarshal(b, target))
+}
+
+// YAMLFrontmatter is frontmatter between --- lines, closed by --- or ...
+type YAMLFrontmatter struct{}
+
+func (YAMLFrontmatter) Split(content []byte) (FrontmatterBlock, bool, error) {
+	return splitFenced(content, "---", "---", "...")
+}
+
+func (YAMLFrontmatter) Decode(content []byte) ([]FrontmatterKey, error) {
+	file, err := parser.ParseBytes(content, 0)
+	if err != nil {
+		line := 1
+		var yamlErr yaml.Error
+		if errors.As(err, &yamlErr) && yamlErr.GetToken() != nil {
+			line = yamlErr.GetToken().Position.Line
+		}
+		return nil, &FrontmatterError{Line: line, Msg: fmt.Sprintf("invalid frontmatter: %v", yamlMessage(err))}
+	}
+	if len(file.Docs) == 0 || file.Docs[0].Body == nil {
+		return nil, nil
+	}
 
-		if inFrontmatter {
-			frontmatterLines = append(frontmatterLines, line)
+	mapping, ok := file.Docs[0].Body.(*ast.MappingNode)
+	if !ok {
+		value, ok := file.Docs[0].Body.(*ast.MappingValueNode)
+		if !ok {
+			return nil, &FrontmatterError{Line: file.Docs[0].Body.GetToken().Position.Line, Msg: "frontmatter must be keys with values"}
 		}
+		mapping = &ast.MappingNode{Values: []*ast.MappingValueNode{value}}
 	}
 
-	if inFrontmatter {
-		return "", errors.New("frontmatter not closed with '---'")
-	} else if len(frontmatterLines) == 0 {
-		return "", errors.New("no frontmatter found")
-	} else if err := scanner.Err(); err != nil {
-		return "", err
+	var keys []FrontmatterKey
+	for _, entry := range mapping.Values {
+		keys = append(keys, FrontmatterKey{
+			Name: entry.Key.GetToken().Value,
+			Line: entry.Key.GetToken().Position.Line,
+			Decode: func(target any) error {
+				return yamlMessage(yaml.NodeToValue(entry.Value, target))
+			},
+		})
 	}
+	return keys, nil
+}
 
-	frontmatter := strings.Join(frontmatterLines, "\n")
+// TOMLFrontmatter is frontmatter between +++ lines, as Hugo and Zola write it.
+// Tables are keys of the frontmatter, the keys in them are part of their value.
+type TOMLFrontmatter struct{}
 
-	return frontmatter, nil
+func (TOMLFrontmatter) Split(content []byte) (FrontmatterBlock, bool, error) {
+	return splitFenced(content, "+++", "+++")
 }
 
-func ParseFrontmatter(content string) (*Frontmatter, error) {
-	var fm Frontmatter
+func (TOMLFrontmatter) Decode(content []byte) ([]FrontmatterKey, error) {
+	values := make(map[string]any)
+	if err := toml.Unmarshal(content, &values); err != nil {
+		var decodeErr *toml.DecodeError
+		if errors.As(err, &decodeErr) {
+			line, _ := decodeErr.Position()
+			return nil, &FrontmatterError{Line: line, Msg: fmt.Sprintf("invalid frontmatter: %v", decodeErr)}
+		}
+		return nil, err
+	}
+
+	// The values are decoded already, the parser finds the line each top-level key is first written on.
+	// Key-values after a table header are in that table.
+	var keys []FrontmatterKey
+	seen := make(map[string]bool)
+	inTable := false
+	p := unstable.Parser{}
+	p.Reset(content)
+	for p.NextExpression() {
+		expr := p.Expression()
+		switch expr.Kind {
+		case unstable.Table, unstable.ArrayTable:
+			inTable = true
+		case unstable.KeyValue:
+			if inTable {
+				continue
+			}
+		default:
+			continue
+		}
 
-	if err := yaml.Unmarshal([]byte(content), &fm); err != nil {
+		key := expr.Key()
+		key.Next()
+		name := string(key.Node().Data)
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		keys = append(keys, FrontmatterKey{
+			Name: name,
+			Line: p.Shape(key.Node().Raw).Start.Line,
+			Decode: func(target any) error {
+				return decodeValue(values[name], target)
+			},
+		})
+	}
+	if err := p.Error(); err != nil {
 		return nil, err
 	}
+	return keys, nil
+}
+
+// JSONFrontmatter is a JSON object at the start of a page. The object has to open on a line of its own,
+// or with a key, so a page starting with a directive like {sidenote} is not taken for frontmatter.
+type JSONFrontmatter struct{}
+
+func (JSONFrontmatter) Split(content []byte) (FrontmatterBlock, bool, error) {
+	first, _, _ := bytes.Cut(content, []byte("\n"))
+	first = bytes.TrimSpace(first)
+	if !bytes.Equal(first, []byte("{")) && !bytes.HasPrefix(first, []byte(`{"`)) {
+		return FrontmatterBlock{}, false, nil
+	}
 
-	return &fm, nil
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	var object json.RawMessage
+	if err := decoder.Decode(&object); err != nil {
+		return FrontmatterBlock{}, true, jsonError(content, decoder, err)
+	}
+
+	// The body starts on the line after the object
+	end := int(decoder.InputOffset())
+	if newline := bytes.IndexByte(content[end:], '\n'); newline >= 0 {
+		end += newline + 1
+	} else {
+		end = len(content)
+	}
+	return FrontmatterBlock{Content: content[:decoder.InputOffset()], Line: 1, Body: content[end:]}, true, nil
 }
 
-func RemoveFrontmatter(content string) string {
-	lines := strings.Split(content, "\n")
-	var result []string
-	inFrontmatter := false
-	frontmatterEnded := false
+func (JSONFrontmatter) Decode(content []byte) ([]FrontmatterKey, error) {
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	if token, err := decoder.Token(); err != nil || token != json.Delim('{') {
+		return nil, &FrontmatterError{Line: 1, Msg: "frontmatter must be a JSON object"}
+	}
 
-	for _, line := range lines {
-		if strings.TrimSpace(line) == "---" && !frontmatterEnded {
-			if inFrontmatter {
-				frontmatterEnded = true
-				inFrontmatter = false
-			} else {
-				inFrontmatter = true
-			}
-			continue // Skip the frontmatter delimiter
+	var keys []FrontmatterKey
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, jsonError(content, decoder, err)
 		}
-		if !inFrontmatter {
-			result = append(result, line)
+		line := 1 + bytes.Count(content[:decoder.InputOffset()], []byte("\n"))
+
+		var value json.RawMessage
+		if err := decoder.Decode(&value); err != nil {
+			return nil, jsonError(content, decoder, err)
 		}
+		keys = append(keys, FrontmatterKey{
+			Name: token.(string),
+			Line: line,
+			Decode: func(target any) error {
+				return yamlMessage(yaml.Unmarshal(value, target))
+			},
+		})
 	}
+	return keys, nil
+}
 
-	return strings.Join(result, "\n")
+// jsonError is an error decoding the JSON frontmatter in content, at the line decoding stopped on
+func jsonError(content []byte, decoder *json.Decoder, err error) error {
+	offset := int(decoder.InputOffset())
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		offset = int(syntaxErr.Offset)
+	}
+	line := 1 + bytes.Count(content[:min(offset, len(content))], []byte("\n"))
+	return &FrontmatterError{Line: line, Msg: fmt.Sprintf("invalid frontmatter: %v", err)}
+}
+
+// validateTerms drops the terms without a URL, which have no letters or numbers, keeping the others
+func validateTerms(terms *Terms) error {
+	var invalid []string
+	*terms = slices.DeleteFunc(*terms, func(term string) bool {
+		if slug.Make(term) == "" {
+			invalid = append(invalid, strconv.Quote(term))
+			return true
+		}
+		return false
+	})
+	if len(invalid) > 0 {
+		return fmt.Errorf("%s has no letters or numbers", strings.Join(invalid, ", "))
+	}
+	return nil
+}
+
+// dateLayout is how dates are written in frontmatter
+const dateLayout = "2006-01-02"
+
+// FormatDate formats a frontmatter date for readers
+func FormatDate(date Timestamp) string {
+	return date.Format("January 2, 2006")
+}
+
+// ReadingTime returns the minutes it takes to read words at wpm words per minute, rounded up
+func ReadingTime(words int, wpm int) int {
+	if words <= 0 || wpm <= 0 {
+		return 0
+	}
+	return (words + wpm - 1) / wpm
 }
@@ -0,0 +1,22 @@
+package src
+
+import "testing"
+
+func TestReadingTime(t *testing.T) {
+	tests := []struct {
+		words, wpm, want int
+	}{
+		{0, 200, 0},
+		{1, 200, 1},
+		{200, 200, 1},
+		{201, 200, 2}, // Rounded up
+		{1000, 250, 4},
+		{500, 0, 0}, // Unknown reading speed
+	}
+
+	for _, test := range tests {
+		if got := ReadingTime(test.words, test.wpm); got != test.want {
+			t.Errorf("%d words at %d wpm: got %d minutes, want %d", test.words, test.wpm, got, test.want)
+		}
+	}
+}
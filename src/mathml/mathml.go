@@ -0,0 +1,647 @@
+// Package mathml converts the common subset of TeX math used in pages to MathML,
+// so math can be rendered on the server instead of by MathJax in the browser.
+package mathml
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// UnsupportedError is returned for TeX the converter does not handle, which has to be typeset by MathJax instead
+type UnsupportedError struct {
+	Construct string
+}
+
+func (e *UnsupportedError) Error() string {
+	return fmt.Sprintf("unsupported TeX %s", e.Construct)
+}
+
+// Convert converts TeX math to a MathML <math> element, display math is rendered as a block
+func Convert(tex string, display bool) (string, error) {
+	c := &converter{input: tex, display: display}
+	items, err := c.row()
+	if err != nil {
+		return "", err
+	}
+	if t := c.next(); t.val != "" {
+		if t.command {
+			return "", &UnsupportedError{Construct: t.val}
+		}
+		return "", fmt.Errorf("unexpected %s", t.val)
+	}
+
+	attrs := ""
+	if display {
+		attrs = ` display="block"`
+	}
+	annotation := `<annotation encoding="application/x-tex">` + html.EscapeString(strings.TrimSpace(tex)) + `</annotation>`
+	return "<math" + attrs + "><semantics>" + mrow(items) + annotation + "</semantics></math>", nil
+}
+
+type token struct {
+	val     string
+	command bool // Control sequence like \frac or \{
+}
+
+// converter reads TeX and writes MathML as it goes, in a single pass
+type converter struct {
+	input   string
+	current int
+	display bool
+}
+
+func (c *converter) skipSpace() {
+	for c.current < len(c.input) && isSpace(c.input[c.current]) {
+		c.current++
+	}
+}
+
+// next reads the next token, or the empty token at the end of the input
+func (c *converter) next() token {
+	c.skipSpace()
+	if c.current >= len(c.input) {
+		return token{}
+	}
+
+	start := c.current
+	ch := c.input[c.current]
+	switch {
+	case ch == '\\':
+		c.current++
+		if c.current >= len(c.input) {
+			return token{val: `\`, command: true}
+		}
+		if isLetter(c.input[c.current]) {
+			for c.current < len(c.input) && isLetter(c.input[c.current]) {
+				c.current++
+			}
+		} else {
+			_, size := utf8.DecodeRuneInString(c.input[c.current:])
+			c.current += size
+		}
+		return token{val: c.input[start:c.current], command: true}
+	case isDigit(ch):
+		for c.current < len(c.input) && (isDigit(c.input[c.current]) || c.input[c.current] == '.' && c.current+1 < len(c.input) && isDigit(c.input[c.current+1])) {
+			c.current++
+		}
+	default:
+		_, size := utf8.DecodeRuneInString(c.input[c.current:])
+		c.current += size
+	}
+	return token{val: c.input[start:c.current]}
+}
+
+func (c *converter) peek() token {
+	current := c.current
+	t := c.next()
+	c.current = current
+	return t
+}
+
+// row parses atoms up to the end of the input, or up to a closing brace, &, \\, \right or \end, which are not consumed
+func (c *converter) row() ([]string, error) {
+	var items []string
+	for {
+		t := c.peek()
+		if t.val == "" || !t.command && (t.val == "}" || t.val == "&") || t.command && (t.val == `\\` || t.val == `\cr` || t.val == `\right` || t.val == `\end`) {
+			return items, nil
+		}
+
+		item, err := c.scripted()
+		if err != nil {
+			return nil, err
+		}
+		if item != "" {
+			items = append(items, item)
+		}
+	}
+}
+
+// scripted parses an atom with its subscript, superscript and primes
+func (c *converter) scripted() (string, error) {
+	base, limits := "<mrow></mrow>", false
+	if t := c.peek(); t.command || t.val != "^" && t.val != "_" {
+		var err error
+		if base, limits, err = c.atom(); err != nil || base == "" {
+			return base, err
+		}
+	}
+
+	switch c.peek().val {
+	case `\limits`:
+		c.next()
+		limits = true
+	case `\nolimits`:
+		c.next()
+		limits = false
+	}
+
+	var sub, sup, primes string
+scripts:
+	for {
+		t := c.peek()
+		if t.command {
+			break
+		}
+		switch t.val {
+		case "^", "_":
+			c.next()
+			arg, err := c.argument()
+			if err != nil {
+				return "", err
+			}
+			if t.val == "^" {
+				if sup != "" {
+					return "", errors.New("double superscript")
+				}
+				sup = arg
+			} else {
+				if sub != "" {
+					return "", errors.New("double subscript")
+				}
+				sub = arg
+			}
+		case "'":
+			c.next()
+			primes += "′"
+		default:
+			break scripts
+		}
+	}
+	if primes != "" {
+		primes = "<mo>" + primes + "</mo>"
+		if sup != "" {
+			primes = "<mrow>" + primes + sup + "</mrow>"
+		}
+		sup = primes
+	}
+
+	under, over, underOver := "msub", "msup", "msubsup"
+	if limits && c.display {
+		under, over, underOver = "munder", "mover", "munderover"
+	}
+	switch {
+	case sub != "" && sup != "":
+		return "<" + underOver + ">" + base + sub + sup + "</" + underOver + ">", nil
+	case sub != "":
+		return "<" + under + ">" + base + sub + "</" + under + ">", nil
+	case sup != "":
+		return "<" + over + ">" + base + sup + "</" + over + ">", nil
+	}
+	return base, nil
+}
+
+// argument parses the argument of a command or script, either a group or a single atom
+func (c *converter) argument() (string, error) {
+	t := c.peek()
+	if t.val == "" || !t.command && (t.val == "}" || t.val == "&" || t.val == "^" || t.val == "_") {
+		return "", errors.New("missing argument")
+	}
+	item, _, err := c.atom()
+	if item == "" && err == nil {
+		item = "<mrow></mrow>"
+	}
+	return item, err
+}
+
+// atom parses a single token or group, and reports whether scripts go under and over it in display math
+func (c *converter) atom() (string, bool, error) {
+	t := c.next()
+	if t.command {
+		return c.command(t.val)
+	}
+
+	r, _ := utf8.DecodeRuneInString(t.val)
+	switch {
+	case t.val == "{":
+		items, err := c.row()
+		if err != nil {
+			return "", false, err
+		}
+		if t := c.next(); t.val != "}" || t.command {
+			return "", false, errors.New("missing closing brace")
+		}
+		return mrow(items), false, nil
+	case t.val == "}" || t.val == "&" || t.val == "#":
+		return "", false, fmt.Errorf("unexpected %s", t.val)
+	case isDigit(t.val[0]):
+		return "<mn>" + t.val + "</mn>", false, nil
+	case unicode.IsLetter(r):
+		return "<mi>" + t.val + "</mi>", false, nil
+	case t.val == "~":
+		return `<mtext>&#160;</mtext>`, false, nil
+	}
+	return mo(t.val), false, nil
+}
+
+// command parses a control sequence and its arguments
+func (c *converter) command(name string) (string, bool, error) {
+	if s, ok := greek[name]; ok {
+		if r, _ := utf8.DecodeRuneInString(s); unicode.IsUpper(r) {
+			return `<mi mathvariant="normal">` + s + "</mi>", false, nil
+		}
+		return "<mi>" + s + "</mi>", false, nil
+	}
+	if s, ok := identifiers[name]; ok {
+		return "<mi>" + s + "</mi>", false, nil
+	}
+	if s, ok := operators[name]; ok {
+		return mo(s), limits[name], nil
+	}
+	if s, ok := functions[name]; ok {
+		return "<mi>" + s + "</mi>", limits[name], nil
+	}
+	if width, ok := spaces[name]; ok {
+		return `<mspace width="` + width + `"/>`, false, nil
+	}
+	if accent, ok := accents[name]; ok {
+		arg, err := c.argument()
+		if err != nil {
+			return "", false, err
+		}
+		return `<mover accent="true">` + arg + "<mo>" + accent + "</mo></mover>", false, nil
+	}
+	if size, ok := sizes[name]; ok {
+		d, err := c.delimiter()
+		if err != nil {
+			return "", false, err
+		}
+		return `<mo minsize="` + size + `" maxsize="` + size + `">` + d + "</mo>", false, nil
+	}
+
+	switch name {
+	case `\frac`, `\dfrac`, `\tfrac`, `\cfrac`:
+		num, den, err := c.arguments()
+		return "<mfrac>" + num + den + "</mfrac>", false, err
+	case `\binom`:
+		n, k, err := c.arguments()
+		return `<mrow><mo>(</mo><mfrac linethickness="0">` + n + k + `</mfrac><mo>)</mo></mrow>`, false, err
+	case `\sqrt`:
+		index, hasIndex, err := c.optional()
+		if err != nil {
+			return "", false, err
+		}
+		arg, err := c.argument()
+		if err != nil || !hasIndex {
+			return "<msqrt>" + arg + "</msqrt>", false, err
+		}
+		sub := &converter{input: index}
+		items, err := sub.row()
+		if err != nil {
+			return "", false, err
+		}
+		return "<mroot>" + arg + mrow(items) + "</mroot>", false, nil
+	case `\overline`:
+		arg, err := c.argument()
+		return `<mover accent="true">` + arg + `<mo stretchy="true">‾</mo></mover>`, false, err
+	case `\underline`:
+		arg, err := c.argument()
+		return `<munder accentunder="true">` + arg + `<mo stretchy="true">_</mo></munder>`, false, err
+	case `\overbrace`:
+		arg, err := c.argument()
+		return `<mover>` + arg + `<mo stretchy="true">⏞</mo></mover>`, true, err
+	case `\underbrace`:
+		arg, err := c.argument()
+		return `<munder>` + arg + `<mo stretchy="true">⏟</mo></munder>`, true, err
+	case `\overset`, `\stackrel`:
+		over, base, err := c.arguments()
+		return "<mover>" + base + over + "</mover>", false, err
+	case `\underset`:
+		under, base, err := c.arguments()
+		return "<munder>" + base + under + "</munder>", false, err
+	case `\text`, `\textrm`, `\textnormal`, `\mbox`:
+		text, err := c.rawArgument()
+		if err != nil || strings.ContainsAny(text, `\$`) {
+			return "", false, &UnsupportedError{Construct: name + "{" + text + "}"}
+		}
+		return "<mtext>" + html.EscapeString(text) + "</mtext>", false, nil
+	case `\operatorname`:
+		text, err := c.rawArgument()
+		if err != nil || strings.ContainsAny(text, `\{}^_`) {
+			return "", false, &UnsupportedError{Construct: name + "{" + text + "}"}
+		}
+		return "<mi>" + html.EscapeString(strings.TrimSpace(text)) + "</mi>", false, nil
+	case `\mathrm`, `\mathit`, `\mathbf`, `\mathbb`, `\mathcal`, `\mathscr`, `\mathfrak`, `\mathsf`, `\mathtt`, `\boldsymbol`:
+		text, err := c.rawArgument()
+		if err != nil {
+			return "", false, err
+		}
+		s, err := styled(name, text)
+		return s, false, err
+	case `\not`:
+		t := c.next()
+		if t.val == "=" {
+			return mo("≠"), false, nil
+		}
+		if s, ok := operators[t.val]; ok {
+			return mo(s + "̸"), false, nil
+		}
+		return "", false, &UnsupportedError{Construct: `\not` + t.val}
+	case `\left`:
+		return c.fenced()
+	case `\middle`:
+		d, err := c.delimiter()
+		return `<mo fence="true" stretchy="true">` + d + "</mo>", false, err
+	case `\begin`:
+		return c.environment()
+	case `\pmod`:
+		arg, err := c.argument()
+		return `<mrow><mspace width="1em"/><mo>(</mo><mi>mod</mi><mspace width="0.3333em"/>` + arg + `<mo>)</mo></mrow>`, false, err
+	case `\bmod`:
+		return "<mo>mod</mo>", false, nil
+	case `\displaystyle`, `\textstyle`, `\hline`, `\nonumber`, `\notag`:
+		// Only affect layout, which the browser handles well enough without them
+		return "", false, nil
+	}
+
+	return "", false, &UnsupportedError{Construct: name}
+}
+
+// arguments parses the two arguments of commands like \frac
+func (c *converter) arguments() (string, string, error) {
+	first, err := c.argument()
+	if err != nil {
+		return "", "", err
+	}
+	second, err := c.argument()
+	return first, second, err
+}
+
+// rawArgument returns the source of a {...} argument without converting it, or of a single token
+func (c *converter) rawArgument() (string, error) {
+	c.skipSpace()
+	if c.current >= len(c.input) || c.input[c.current] != '{' {
+		t := c.next()
+		if t.val == "" {
+			return "", errors.New("missing argument")
+		}
+		return t.val, nil
+	}
+
+	depth := 0
+	for i := c.current; i < len(c.input); i++ {
+		switch c.input[i] {
+		case '\\':
+			i++
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				text := c.input[c.current+1 : i]
+				c.current = i + 1
+				return text, nil
+			}
+		}
+	}
+	return "", errors.New("missing closing brace")
+}
+
+// optional returns the source of an optional [...] argument, like the index of \sqrt[3]{x}
+func (c *converter) optional() (string, bool, error) {
+	c.skipSpace()
+	if c.current >= len(c.input) || c.input[c.current] != '[' {
+		return "", false, nil
+	}
+
+	depth := 0
+	for i := c.current + 1; i < len(c.input); i++ {
+		switch c.input[i] {
+		case '\\':
+			i++
+		case '{':
+			depth++
+		case '}':
+			depth--
+		case ']':
+			if depth == 0 {
+				text := c.input[c.current+1 : i]
+				c.current = i + 1
+				return text, true, nil
+			}
+		}
+	}
+	return "", false, errors.New("missing closing bracket")
+}
+
+// fenced parses \left( ... \right) after the \left
+func (c *converter) fenced() (string, bool, error) {
+	open, err := c.delimiter()
+	if err != nil {
+		return "", false, err
+	}
+	items, err := c.row()
+	if err != nil {
+		return "", false, err
+	}
+	if t := c.next(); t.val != `\right` {
+		return "", false, errors.New(`\left without a matching \right`)
+	}
+	closing, err := c.delimiter()
+	if err != nil {
+		return "", false, err
+	}
+
+	return "<mrow>" + fence(open) + strings.Join(items, "") + fence(closing) + "</mrow>", false, nil
+}
+
+// delimiter reads the delimiter after \left, \right, \middle or \big, where . is no delimiter
+func (c *converter) delimiter() (string, error) {
+	t := c.next()
+	if t.command {
+		if s, ok := operators[t.val]; ok {
+			return html.EscapeString(s), nil
+		}
+	} else {
+		switch t.val {
+		case ".":
+			return "", nil
+		case "(", ")", "[", "]", "|", "/":
+			return t.val, nil
+		case "<":
+			return "⟨", nil
+		case ">":
+			return "⟩", nil
+		}
+	}
+	return "", fmt.Errorf("invalid delimiter %q", t.val)
+}
+
+func fence(d string) string {
+	if d == "" {
+		return ""
+	}
+	return `<mo fence="true" stretchy="true">` + d + "</mo>"
+}
+
+// environment parses \begin{name} ... \end{name} after the \begin, as a table
+func (c *converter) environment() (string, bool, error) {
+	name, err := c.rawArgument()
+	if err != nil {
+		return "", false, err
+	}
+
+	var open, closing string
+	var align []string
+	displayStyle := false
+	switch name {
+	case "matrix", "smallmatrix":
+	case "pmatrix":
+		open, closing = "(", ")"
+	case "bmatrix":
+		open, closing = "[", "]"
+	case "Bmatrix":
+		open, closing = "{", "}"
+	case "vmatrix":
+		open, closing = "|", "|"
+	case "Vmatrix":
+		open, closing = "‖", "‖"
+	case "cases":
+		open, align = "{", []string{"left"}
+	case "aligned", "align", "align*", "split":
+		align, displayStyle = []string{"right", "left"}, true
+	case "gathered", "gather", "gather*":
+		displayStyle = true
+	case "array":
+		spec, err := c.rawArgument()
+		if err != nil {
+			return "", false, err
+		}
+		for _, column := range spec {
+			switch column {
+			case 'l':
+				align = append(align, "left")
+			case 'c':
+				align = append(align, "center")
+			case 'r':
+				align = append(align, "right")
+			}
+		}
+	default:
+		return "", false, &UnsupportedError{Construct: `\begin{` + name + `}`}
+	}
+
+	rows, err := c.table(name)
+	if err != nil {
+		return "", false, err
+	}
+
+	var sb strings.Builder
+	sb.WriteString("<mrow>" + fence(open))
+	if displayStyle {
+		sb.WriteString(`<mtable displaystyle="true">`)
+	} else {
+		sb.WriteString("<mtable>")
+	}
+	for _, row := range rows {
+		sb.WriteString("<mtr>")
+		for i, cell := range row {
+			if len(align) > 0 {
+				sb.WriteString(`<mtd columnalign="` + align[i%len(align)] + `">`)
+			} else {
+				sb.WriteString("<mtd>")
+			}
+			sb.WriteString(cell + "</mtd>")
+		}
+		sb.WriteString("</mtr>")
+	}
+	sb.WriteString("</mtable>" + fence(closing) + "</mrow>")
+
+	return sb.String(), false, nil
+}
+
+// table parses the cells of an environment up to its \end, with cells separated by & and rows by \\
+func (c *converter) table(name string) ([][]string, error) {
+	var rows [][]string
+	var cells []string
+	for {
+		items, err := c.row()
+		if err != nil {
+			return nil, err
+		}
+		cells = append(cells, strings.Join(items, ""))
+
+		t := c.next()
+		switch {
+		case t.val == "&" && !t.command:
+		case t.val == `\\` || t.val == `\cr`:
+			// Row spacing, like \\[2pt]
+			if _, _, err := c.optional(); err != nil {
+				return nil, err
+			}
+			rows = append(rows, cells)
+			cells = nil
+		case t.val == `\end`:
+			end, err := c.rawArgument()
+			if err != nil {
+				return nil, err
+			}
+			if end != name {
+				return nil, fmt.Errorf(`\begin{%s} ended by \end{%s}`, name, end)
+			}
+			// A trailing \\ does not start another row
+			if len(cells) > 1 || cells[0] != "" {
+				rows = append(rows, cells)
+			}
+			return rows, nil
+		default:
+			return nil, fmt.Errorf(`missing \end{%s}`, name)
+		}
+	}
+}
+
+// styled converts the text of font commands like \mathbb{R} to the matching Unicode math letters
+func styled(command string, text string) (string, error) {
+	if strings.ContainsAny(text, `\{}^_$`) {
+		return "", &UnsupportedError{Construct: command + "{" + text + "}"}
+	}
+	text = strings.Join(strings.Fields(text), "")
+
+	switch command {
+	case `\mathrm`:
+		return `<mi mathvariant="normal">` + html.EscapeString(text) + "</mi>", nil
+	case `\mathit`:
+		var sb strings.Builder
+		for _, r := range text {
+			sb.WriteString("<mi>" + html.EscapeString(string(r)) + "</mi>")
+		}
+		return sb.String(), nil
+	}
+
+	a := alphabets[command]
+	var sb strings.Builder
+	for _, r := range text {
+		sb.WriteRune(a.letter(r))
+	}
+	return "<mi>" + html.EscapeString(sb.String()) + "</mi>", nil
+}
+
+func mo(s string) string {
+	if s == "-" {
+		s = "−"
+	}
+	return "<mo>" + html.EscapeString(s) + "</mo>"
+}
+
+func mrow(items []string) string {
+	if len(items) == 1 {
+		return items[0]
+	}
+	return "<mrow>" + strings.Join(items, "") + "</mrow>"
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
+
+func isLetter(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
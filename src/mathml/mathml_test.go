@@ -0,0 +1,85 @@
+package mathml
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestConvert(t *testing.T) {
+	tests := []struct {
+		name    string
+		tex     string
+		display bool
+		want    string // MathML inside the <semantics> element, without the annotation
+	}{
+		{"identifiers and numbers", "2x + 3.5", false, "<mrow><mn>2</mn><mi>x</mi><mo>+</mo><mn>3.5</mn></mrow>"},
+		{"minus", "a-b", false, "<mrow><mi>a</mi><mo>−</mo><mi>b</mi></mrow>"},
+		{"scripts", "x_i^{2}", false, "<msubsup><mi>x</mi><mi>i</mi><mn>2</mn></msubsup>"},
+		{"prime", "f'", false, "<msup><mi>f</mi><mo>′</mo></msup>"},
+		{"fraction", `\frac{a}{b}`, false, "<mfrac><mi>a</mi><mi>b</mi></mfrac>"},
+		{"root", `\sqrt[3]{x}`, false, "<mroot><mi>x</mi><mn>3</mn></mroot>"},
+		{"greek", `\alpha\Omega`, false, `<mrow><mi>α</mi><mi mathvariant="normal">Ω</mi></mrow>`},
+		{"inline sum", `\sum_{i=1}^n i`, false, "<mrow><msubsup><mo>∑</mo><mrow><mi>i</mi><mo>=</mo><mn>1</mn></mrow><mi>n</mi></msubsup><mi>i</mi></mrow>"},
+		{"display sum", `\sum_{i=1}^n i`, true, "<mrow><munderover><mo>∑</mo><mrow><mi>i</mi><mo>=</mo><mn>1</mn></mrow><mi>n</mi></munderover><mi>i</mi></mrow>"},
+		{"display integral", `\int_0^1 x\,dx`, true, `<mrow><msubsup><mo>∫</mo><mn>0</mn><mn>1</mn></msubsup><mi>x</mi><mspace width="0.1667em"/><mi>d</mi><mi>x</mi></mrow>`},
+		{"function", `\sin x`, false, "<mrow><mi>sin</mi><mi>x</mi></mrow>"},
+		{"fence", `\left( x \right)`, false, `<mrow><mo fence="true" stretchy="true">(</mo><mi>x</mi><mo fence="true" stretchy="true">)</mo></mrow>`},
+		{"text", `x \text{if } y`, false, "<mrow><mi>x</mi><mtext>if </mtext><mi>y</mi></mrow>"},
+		{"blackboard", `\mathbb{R}^n`, false, "<msup><mi>ℝ</mi><mi>n</mi></msup>"},
+		{"relation", `a \leq b`, false, "<mrow><mi>a</mi><mo>≤</mo><mi>b</mi></mrow>"},
+		{"escaped", `a < b`, false, "<mrow><mi>a</mi><mo>&lt;</mo><mi>b</mi></mrow>"},
+		{"matrix", `\begin{pmatrix} 1 & 0 \\ 0 & 1 \end{pmatrix}`, true, `<mrow><mo fence="true" stretchy="true">(</mo><mtable><mtr><mtd><mn>1</mn></mtd><mtd><mn>0</mn></mtd></mtr><mtr><mtd><mn>0</mn></mtd><mtd><mn>1</mn></mtd></mtr></mtable><mo fence="true" stretchy="true">)</mo></mrow>`},
+		{"aligned", "\\begin{aligned} a &= b \\\\ &= c \\\\ \\end{aligned}", true, `<mrow><mtable displaystyle="true"><mtr><mtd columnalign="right"><mi>a</mi></mtd><mtd columnalign="left"><mo>=</mo><mi>b</mi></mtd></mtr><mtr><mtd columnalign="right"></mtd><mtd columnalign="left"><mo>=</mo><mi>c</mi></mtd></mtr></mtable></mrow>`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := Convert(test.tex, test.display)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			got = strings.TrimPrefix(got, `<math display="block">`)
+			got = strings.TrimPrefix(got, "<math>")
+			got = strings.TrimPrefix(got, "<semantics>")
+			got = got[:strings.Index(got, "<annotation")]
+			if got != test.want {
+				t.Errorf("got  %s\nwant %s", got, test.want)
+			}
+		})
+	}
+}
+
+func TestConvertErrors(t *testing.T) {
+	tests := []struct {
+		tex         string
+		unsupported bool
+	}{
+		{`\frac{a}`, false},
+		{`x^`, false},
+		{`{x`, false},
+		{`\sqrt[3`, false},
+		{`\sqrt[3{x}`, false},
+		{`\begin{matrix} a \\[2pt b \end{matrix}`, false},
+		{`\left( x`, false},
+		{`\begin{matrix} a`, false},
+		{`a \\ b`, true},
+		{`\boxed{x}`, true},
+		{`\begin{tikzcd} a \end{tikzcd}`, true},
+		{`\mathbb{\alpha}`, true},
+	}
+
+	for _, test := range tests {
+		_, err := Convert(test.tex, true)
+		if err == nil {
+			t.Errorf("%s: expected an error", test.tex)
+			continue
+		}
+
+		var unsupported *UnsupportedError
+		if errors.As(err, &unsupported) != test.unsupported {
+			t.Errorf("%s: got error %v, unsupported should be %v", test.tex, err, test.unsupported)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package mathml
+
+var greek = map[string]string{
+	`\alpha`: "α", `\beta`: "β", `\gamma`: "γ", `\delta`: "δ", `\epsilon`: "ϵ", `\varepsilon`: "ε",
+	`\zeta`: "ζ", `\eta`: "η", `\theta`: "θ", `\vartheta`: "ϑ", `\iota`: "ι", `\kappa`: "κ",
+	`\lambda`: "λ", `\mu`: "μ", `\nu`: "ν", `\xi`: "ξ", `\omicron`: "ο", `\pi`: "π", `\varpi`: "ϖ",
+	`\rho`: "ρ", `\varrho`: "ϱ", `\sigma`: "σ", `\varsigma`: "ς", `\tau`: "τ", `\upsilon`: "υ",
+	`\phi`: "ϕ", `\varphi`: "φ", `\chi`: "χ", `\psi`: "ψ", `\omega`: "ω",
+
+	`\Gamma`: "Γ", `\Delta`: "Δ", `\Theta`: "Θ", `\Lambda`: "Λ", `\Xi`: "Ξ", `\Pi`: "Π",
+	`\Sigma`: "Σ", `\Upsilon`: "Υ", `\Phi`: "Φ", `\Psi`: "Ψ", `\Omega`: "Ω",
+}
+
+// Symbols that are rendered as identifiers rather than operators
+var identifiers = map[string]string{
+	`\infty`: "∞", `\emptyset`: "∅", `\varnothing`: "∅", `\ell`: "ℓ", `\hbar`: "ℏ", `\Re`: "ℜ", `\Im`: "ℑ",
+	`\aleph`: "ℵ", `\partial`: "∂", `\nabla`: "∇", `\angle`: "∠", `\triangle`: "△", `\degree`: "°",
+}
+
+var operators = map[string]string{
+	// Binary operators
+	`\pm`: "±", `\mp`: "∓", `\times`: "×", `\div`: "÷", `\cdot`: "⋅", `\ast`: "∗", `\star`: "⋆",
+	`\circ`: "∘", `\bullet`: "∙", `\oplus`: "⊕", `\ominus`: "⊖", `\otimes`: "⊗", `\oslash`: "⊘",
+	`\odot`: "⊙", `\wedge`: "∧", `\land`: "∧", `\vee`: "∨", `\lor`: "∨", `\cap`: "∩", `\cup`: "∪",
+	`\setminus`: "∖", `\neg`: "¬", `\lnot`: "¬",
+
+	// Relations
+	`\leq`: "≤", `\le`: "≤", `\geq`: "≥", `\ge`: "≥", `\neq`: "≠", `\ne`: "≠", `\approx`: "≈",
+	`\equiv`: "≡", `\sim`: "∼", `\simeq`: "≃", `\cong`: "≅", `\propto`: "∝", `\ll`: "≪", `\gg`: "≫",
+	`\prec`: "≺", `\succ`: "≻", `\in`: "∈", `\notin`: "∉", `\ni`: "∋", `\subset`: "⊂", `\supset`: "⊃",
+	`\subseteq`: "⊆", `\supseteq`: "⊇", `\mid`: "∣", `\parallel`: "∥", `\perp`: "⊥", `\vdash`: "⊢",
+	`\models`: "⊨",
+
+	// Arrows
+	`\to`: "→", `\rightarrow`: "→", `\leftarrow`: "←", `\gets`: "←", `\leftrightarrow`: "↔",
+	`\Rightarrow`: "⇒", `\Leftarrow`: "⇐", `\Leftrightarrow`: "⇔", `\implies`: "⟹", `\impliedby`: "⟸",
+	`\iff`: "⟺", `\mapsto`: "↦", `\longrightarrow`: "⟶", `\longleftarrow`: "⟵", `\uparrow`: "↑",
+	`\downarrow`: "↓",
+
+	// Large operators
+	`\sum`: "∑", `\prod`: "∏", `\coprod`: "∐", `\int`: "∫", `\iint`: "∬", `\iiint`: "∭", `\oint`: "∮",
+	`\bigcup`: "⋃", `\bigcap`: "⋂", `\bigoplus`: "⨁", `\bigotimes`: "⨂", `\bigvee`: "⋁", `\bigwedge`: "⋀",
+
+	// Dots and quantifiers
+	`\ldots`: "…", `\dots`: "…", `\cdots`: "⋯", `\vdots`: "⋮", `\ddots`: "⋱", `\forall`: "∀",
+	`\exists`: "∃", `\nexists`: "∄", `\prime`: "′",
+
+	// Delimiters and escaped characters
+	`\{`: "{", `\}`: "}", `\lbrace`: "{", `\rbrace`: "}", `\langle`: "⟨", `\rangle`: "⟩", `\lvert`: "|",
+	`\rvert`: "|", `\vert`: "|", `\|`: "‖", `\Vert`: "‖", `\lVert`: "‖", `\rVert`: "‖", `\lfloor`: "⌊",
+	`\rfloor`: "⌋", `\lceil`: "⌈", `\rceil`: "⌉", `\%`: "%", `\$`: "$", `\&`: "&", `\#`: "#", `\_`: "_",
+}
+
+// Function names, set upright
+var functions = map[string]string{
+	`\sin`: "sin", `\cos`: "cos", `\tan`: "tan", `\cot`: "cot", `\sec`: "sec", `\csc`: "csc",
+	`\arcsin`: "arcsin", `\arccos`: "arccos", `\arctan`: "arctan", `\sinh`: "sinh", `\cosh`: "cosh",
+	`\tanh`: "tanh", `\log`: "log", `\ln`: "ln", `\lg`: "lg", `\exp`: "exp", `\min`: "min", `\max`: "max",
+	`\sup`: "sup", `\inf`: "inf", `\lim`: "lim", `\liminf`: "lim inf", `\limsup`: "lim sup", `\det`: "det",
+	`\dim`: "dim", `\ker`: "ker", `\deg`: "deg", `\gcd`: "gcd", `\arg`: "arg", `\Pr`: "Pr",
+}
+
+// Operators and functions whose scripts go under and over them in display math
+var limits = map[string]bool{
+	`\sum`: true, `\prod`: true, `\coprod`: true, `\bigcup`: true, `\bigcap`: true, `\bigoplus`: true,
+	`\bigotimes`: true, `\bigvee`: true, `\bigwedge`: true, `\lim`: true, `\liminf`: true, `\limsup`: true,
+	`\min`: true, `\max`: true, `\sup`: true, `\inf`: true, `\det`: true, `\gcd`: true, `\Pr`: true,
+}
+
+var spaces = map[string]string{
+	`\,`: "0.1667em", `\:`: "0.2222em", `\>`: "0.2222em", `\;`: "0.2778em", `\!`: "-0.1667em",
+	`\ `: "0.25em", `\quad`: "1em", `\qquad`: "2em",
+}
+
+var accents = map[string]string{
+	`\hat`: "^", `\widehat`: "^", `\bar`: "‾", `\vec`: "→", `\tilde`: "~", `\widetilde`: "~",
+	`\dot`: "˙", `\ddot`: "¨", `\check`: "ˇ", `\breve`: "˘", `\acute`: "´", `\grave`: "`",
+}
+
+// Sizes of delimiters set with \big and friends
+var sizes = map[string]string{
+	`\big`: "1.2em", `\bigl`: "1.2em", `\bigr`: "1.2em", `\bigm`: "1.2em",
+	`\Big`: "1.8em", `\Bigl`: "1.8em", `\Bigr`: "1.8em", `\Bigm`: "1.8em",
+	`\bigg`: "2.4em", `\biggl`: "2.4em", `\biggr`: "2.4em", `\biggm`: "2.4em",
+	`\Bigg`: "3em", `\Biggl`: "3em", `\Biggr`: "3em", `\Biggm`: "3em",
+}
+
+// alphabet maps letters and digits to a style of the Unicode mathematical alphanumeric symbols
+type alphabet struct {
+	upper, lower, digit rune
+	exceptions          map[rune]rune // Letters that were in Unicode before the rest of their style
+}
+
+func (a alphabet) letter(r rune) rune {
+	if e, ok := a.exceptions[r]; ok {
+		return e
+	}
+	switch {
+	case r >= 'A' && r <= 'Z' && a.upper != 0:
+		return a.upper + r - 'A'
+	case r >= 'a' && r <= 'z' && a.lower != 0:
+		return a.lower + r - 'a'
+	case r >= '0' && r <= '9' && a.digit != 0:
+		return a.digit + r - '0'
+	}
+	return r
+}
+
+var alphabets = map[string]alphabet{
+	`\mathbf`:     {upper: 0x1D400, lower: 0x1D41A, digit: 0x1D7CE},
+	`\boldsymbol`: {upper: 0x1D468, lower: 0x1D482, digit: 0x1D7CE},
+	`\mathbb`: {upper: 0x1D538, lower: 0x1D552, digit: 0x1D7D8, exceptions: map[rune]rune{
+		'C': 'ℂ', 'H': 'ℍ', 'N': 'ℕ', 'P': 'ℙ', 'Q': 'ℚ', 'R': 'ℝ', 'Z': 'ℤ',
+	}},
+	`\mathcal`: {upper: 0x1D49C, lower: 0x1D4B6, exceptions: map[rune]rune{
+		'B': 'ℬ', 'E': 'ℰ', 'F': 'ℱ', 'H': 'ℋ', 'I': 'ℐ', 'L': 'ℒ', 'M': 'ℳ', 'R': 'ℛ', 'e': 'ℯ', 'g': 'ℊ', 'o': 'ℴ',
+	}},
+	`\mathscr`: {upper: 0x1D49C, lower: 0x1D4B6, exceptions: map[rune]rune{
+		'B': 'ℬ', 'E': 'ℰ', 'F': 'ℱ', 'H': 'ℋ', 'I': 'ℐ', 'L': 'ℒ', 'M': 'ℳ', 'R': 'ℛ', 'e': 'ℯ', 'g': 'ℊ', 'o': 'ℴ',
+	}},
+	`\mathfrak`: {upper: 0x1D504, lower: 0x1D51E, exceptions: map[rune]rune{
+		'C': 'ℭ', 'H': 'ℌ', 'I': 'ℑ', 'R': 'ℜ', 'Z': 'ℨ',
+	}},
+	`\mathsf`: {upper: 0x1D5A0, lower: 0x1D5BA, digit: 0x1D7E2},
+	`\mathtt`: {upper: 0x1D670, lower: 0x1D68A, digit: 0x1D7F6},
+}
@@ -7,9 +7,15 @@ import (
 )
 
 type File struct {
-	Name     string
-	Path     string
-	Selected bool // Used to indicate if this file is currently selected
+	Name        string
+	Path        string
+	Selected    bool // Used to indicate if this file is currently selected
+	Draft       bool // Not published yet, only shown when previewing the site
+	Unpublished bool // Outside its publishing window, only shown when previewing the site
+	Desc        string
+	Params      Params // Custom keys of the page's frontmatter
+	Tags        []string
+	Categories  []string
 }
 
 type Folder struct {
@@ -67,3 +73,29 @@ func FileTree(folder string, selectedPath string) (Folder, error) {
 
 	return rootFolder, nil
 }
+
+// AllFiles returns every file in the folder and its subfolders, depth first
+func (f Folder) AllFiles() []File {
+	files := append([]File{}, f.Files...)
+	for _, subfolder := range f.Subfolders {
+		files = append(files, subfolder.AllFiles()...)
+	}
+	return files
+}
+
+// Filter returns the folder with only the files keep returns true for, leaving out folders that end up empty.
+// keep may change the files it is given, like marking them as drafts.
+func (f Folder) Filter(keep func(*File) bool) Folder {
+	filtered := Folder{Name: f.Name, Files: []File{}, Subfolders: []Folder{}}
+	for _, file := range f.Files {
+		if keep(&file) {
+			filtered.Files = append(filtered.Files, file)
+		}
+	}
+	for _, subfolder := range f.Subfolders {
+		if subfolder := subfolder.Filter(keep); len(subfolder.Files) > 0 || len(subfolder.Subfolders) > 0 {
+			filtered.Subfolders = append(filtered.Subfolders, subfolder)
+		}
+	}
+	return filtered
+}
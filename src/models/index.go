@@ -0,0 +1,68 @@
+package models
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// PageIndex finds pages by their path, file name or title, for links that name a page rather than its URL
+type PageIndex struct {
+	paths  map[string]string
+	names  map[string][]string
+	titles map[string][]string
+}
+
+// NewPageIndex indexes every file in folder, title returns the title of a file or the empty string if it has none
+func NewPageIndex(folder Folder, title func(File) string) *PageIndex {
+	index := &PageIndex{paths: make(map[string]string), names: make(map[string][]string), titles: make(map[string][]string)}
+	for _, file := range folder.AllFiles() {
+		index.paths[normalizeName(file.Path)] = file.Path
+		name := normalizeName(file.Name)
+		index.names[name] = append(index.names[name], file.Path)
+		if t := normalizeName(title(file)); t != "" {
+			index.titles[t] = append(index.titles[t], file.Path)
+		}
+	}
+	return index
+}
+
+// Resolve returns the path of the page named by target, which is matched against paths,
+// then file names, then titles, ignoring case. A name or title shared by several pages is an error.
+func (i *PageIndex) Resolve(target string) (string, error) {
+	key := normalizeName(strings.TrimSuffix(strings.TrimPrefix(target, "/"), ".md"))
+	if path, ok := i.paths[key]; ok {
+		return path, nil
+	}
+
+	for _, candidates := range []map[string][]string{i.names, i.titles} {
+		paths := candidates[key]
+		switch {
+		case len(paths) == 1:
+			return paths[0], nil
+		case len(paths) > 1:
+			paths = slices.Clone(paths)
+			slices.Sort(paths)
+			return "", fmt.Errorf("%q is ambiguous, it matches %s", target, strings.Join(paths, ", "))
+		}
+	}
+
+	return "", fmt.Errorf("no page named %q", target)
+}
+
+// ByPath returns the path of the page at path, ignoring case, and whether there is one
+func (i *PageIndex) ByPath(path string) (string, bool) {
+	page, ok := i.paths[normalizeName(path)]
+	return page, ok
+}
+
+func normalizeName(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+// Backlink is a page linking to another page
+type Backlink struct {
+	Path    string // Path of the linking page, like the Path of a File
+	Title   string
+	Context string // Text around the link
+}
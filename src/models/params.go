@@ -0,0 +1,101 @@
+package models
+
+import (
+	"fmt"
+	"math"
+)
+
+// Params are the custom keys of a page's frontmatter, like cover, repo or stack, that the site has no field for.
+// Values are as YAML decodes them: strings, bools, numbers, lists and maps. The accessors return
+// the zero value for keys that are missing or of another type, so templates can use them directly.
+type Params map[string]any
+
+// Has reports whether the page sets key
+func (p Params) Has(key string) bool {
+	_, ok := p[key]
+	return ok
+}
+
+// String returns a string, number or bool as text
+func (p Params) String(key string) string {
+	return text(p[key])
+}
+
+// Strings returns a list as text, and a single value as a list of one
+func (p Params) Strings(key string) []string {
+	list, ok := p[key].([]any)
+	if !ok {
+		if s := p.String(key); s != "" {
+			return []string{s}
+		}
+		return nil
+	}
+
+	strings := make([]string, 0, len(list))
+	for _, value := range list {
+		if s := text(value); s != "" {
+			strings = append(strings, s)
+		}
+	}
+	return strings
+}
+
+// Int returns a whole number
+func (p Params) Int(key string) int {
+	switch value := p[key].(type) {
+	case int:
+		return value
+	case int64:
+		return int(value)
+	case uint64:
+		return int(value)
+	case float64:
+		if value == math.Trunc(value) {
+			return int(value)
+		}
+	}
+	return 0
+}
+
+// Float returns a number
+func (p Params) Float(key string) float64 {
+	switch value := p[key].(type) {
+	case int:
+		return float64(value)
+	case int64:
+		return float64(value)
+	case uint64:
+		return float64(value)
+	case float64:
+		return value
+	}
+	return 0
+}
+
+// Bool returns a bool
+func (p Params) Bool(key string) bool {
+	value, _ := p[key].(bool)
+	return value
+}
+
+// Map returns a map of keys, like a TOML table, as Params of its own
+func (p Params) Map(key string) Params {
+	switch value := p[key].(type) {
+	case map[string]any:
+		return Params(value)
+	case Params:
+		return value
+	}
+	return nil
+}
+
+// text formats a string, number or bool, and returns the empty string for other values
+func text(value any) string {
+	switch value := value.(type) {
+	case string:
+		return value
+	case bool, int, int64, uint64, float64:
+		return fmt.Sprint(value)
+	}
+	return ""
+}
@@ -0,0 +1,70 @@
+package models
+
+import (
+	"slices"
+	"strings"
+
+	"website/src/slug"
+)
+
+// Taxonomy groups pages by the terms their frontmatter gives them, like their tags or categories
+type Taxonomy struct {
+	Name  string // Plural name of the taxonomy, which is also the route it is served at, like tags
+	Title string
+	Terms []Term // Ordered by slug
+}
+
+// Term is a tag or category with the pages that have it
+type Term struct {
+	Name  string // As the first page with the term writes it
+	Slug  string // Identifies the term in its URL, terms written with other cases or punctuation are the same term
+	Files []File
+}
+
+// NewTaxonomy groups every file in folder by its terms
+func NewTaxonomy(name string, title string, folder Folder, terms func(File) []string) Taxonomy {
+	taxonomy := Taxonomy{Name: name, Title: title}
+	index := make(map[string]int)
+	for _, file := range folder.AllFiles() {
+		for _, term := range terms(file) {
+			id := slug.Make(term)
+			if id == "" {
+				continue
+			}
+			i, ok := index[id]
+			if !ok {
+				i = len(taxonomy.Terms)
+				index[id] = i
+				taxonomy.Terms = append(taxonomy.Terms, Term{Name: term, Slug: id})
+			}
+			// A page that writes a term twice is listed once
+			if files := taxonomy.Terms[i].Files; len(files) == 0 || files[len(files)-1].Path != file.Path {
+				taxonomy.Terms[i].Files = append(files, file)
+			}
+		}
+	}
+
+	slices.SortFunc(taxonomy.Terms, func(a, b Term) int {
+		return strings.Compare(a.Slug, b.Slug)
+	})
+	return taxonomy
+}
+
+// Term returns the term with slug
+func (t Taxonomy) Term(slug string) (Term, bool) {
+	i := slices.IndexFunc(t.Terms, func(term Term) bool { return term.Slug == slug })
+	if i < 0 {
+		return Term{}, false
+	}
+	return t.Terms[i], true
+}
+
+// URL returns the route of a term of the taxonomy
+func (t Taxonomy) URL(term Term) string {
+	return TermURL(t.Name, term.Name)
+}
+
+// TermURL returns the route of the term of the taxonomy called name, like /tags/static-sites
+func TermURL(name string, term string) string {
+	return "/" + name + "/" + slug.Make(term)
+}
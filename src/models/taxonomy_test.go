@@ -0,0 +1,44 @@
+package models
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNewTaxonomy(t *testing.T) {
+	folder := Folder{
+		Files: []File{
+			{Path: "a", Tags: []string{"Static Sites", "go"}},
+			{Path: "b", Tags: []string{"static-sites", "static sites", "???"}},
+		},
+		Subfolders: []Folder{{Files: []File{
+			{Path: "c/d", Tags: []string{"GO"}},
+			{Path: "c/e"},
+		}}},
+	}
+	taxonomy := NewTaxonomy("tags", "Tags", folder, func(file File) []string { return file.Tags })
+
+	// Terms with the same slug are one term, named as the first page writes it and ordered by slug
+	var got []string
+	for _, term := range taxonomy.Terms {
+		var paths []string
+		for _, file := range term.Files {
+			paths = append(paths, file.Path)
+		}
+		got = append(got, term.Name+" "+term.Slug+" "+strings.Join(paths, ","))
+	}
+	want := "go go a,c/d; Static Sites static-sites a,b"
+	if strings.Join(got, "; ") != want {
+		t.Errorf("got terms %q, want %q", strings.Join(got, "; "), want)
+	}
+
+	if term, ok := taxonomy.Term("static-sites"); !ok || term.Name != "Static Sites" {
+		t.Errorf("got term %+v, %v", term, ok)
+	}
+	if _, ok := taxonomy.Term("Static Sites"); ok {
+		t.Error("terms are found by their slug, not their name")
+	}
+	if url := taxonomy.URL(taxonomy.Terms[1]); url != "/tags/static-sites" {
+		t.Errorf("got URL %s", url)
+	}
+}
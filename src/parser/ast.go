@@ -0,0 +1,78 @@
+package parser
+
+import (
+	"strings"
+
+	"github.com/gomarkdown/markdown/ast"
+)
+
+// nodeText concatenates the literal text of all leaves below node
+func nodeText(node ast.Node) string {
+	var sb strings.Builder
+	ast.WalkFunc(node, func(n ast.Node, entering bool) ast.WalkStatus {
+		switch n := n.(type) {
+		case *ast.Text:
+			sb.Write(n.Literal)
+		case *ast.Code:
+			sb.Write(n.Literal)
+		}
+		return ast.GoToNext
+	})
+	return sb.String()
+}
+
+// WordCount returns the number of words in the text of a document, leaving out code blocks and math.
+// Paragraphs, headings and table cells are counted one by one, so words at their edges are not joined.
+func WordCount(doc ast.Node) int {
+	words := 0
+	ast.WalkFunc(doc, func(n ast.Node, entering bool) ast.WalkStatus {
+		if !entering {
+			return ast.GoToNext
+		}
+		switch n.(type) {
+		case *ast.Paragraph, *ast.Heading, *ast.TableCell:
+			words += len(strings.Fields(nodeText(n)))
+			return ast.SkipChildren
+		}
+		return ast.GoToNext
+	})
+	return words
+}
+
+// appendChild moves child to the end of parent's children.
+// Unlike ast.AppendChild it keeps the children of a node that is moved from another parent.
+func appendChild(parent ast.Node, child ast.Node) {
+	child.SetParent(nil)
+	ast.AppendChild(parent, child)
+}
+
+// insertAfter inserts node into the parent of sibling, right after sibling
+func insertAfter(sibling ast.Node, node ast.Node) {
+	parent := sibling.GetParent()
+	children := parent.GetChildren()
+	for i, child := range children {
+		if child == sibling {
+			children = append(children[:i+1], append([]ast.Node{node}, children[i+1:]...)...)
+			break
+		}
+	}
+	node.SetParent(parent)
+	parent.SetChildren(children)
+}
+
+// replaceNode replaces node with the given nodes in the children of its parent
+func replaceNode(node ast.Node, nodes ...ast.Node) {
+	parent := node.GetParent()
+	var children []ast.Node
+	for _, child := range parent.GetChildren() {
+		if child == node {
+			children = append(children, nodes...)
+		} else {
+			children = append(children, child)
+		}
+	}
+	for _, n := range nodes {
+		n.SetParent(parent)
+	}
+	parent.SetChildren(children)
+}
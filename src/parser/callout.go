@@ -0,0 +1,135 @@
+package parser
+
+import (
+	"bytes"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/gomarkdown/markdown/ast"
+)
+
+// Callout is a blockquote starting with a [!KIND] marker, like GitHub alerts and Obsidian callouts:
+//
+//	> [!WARNING]- Custom title
+//	> Content of the callout
+//
+// Its first child is the CalloutTitle, the rest is its content.
+type Callout struct {
+	ast.Container
+	Kind     string // Lowercase kind, like note or warning
+	Foldable bool   // Marked with - or +, to be rendered as a collapsible element
+	Open     bool   // Shown expanded, unless marked with -
+}
+
+// CalloutTitle holds the inline content of a callout's title
+type CalloutTitle struct {
+	ast.Container
+}
+
+var calloutRegex = regexp.MustCompile(`^\[!([A-Za-z][\w-]*)\]([+-]?)[ \t]*`)
+
+// ProcessCallouts turns blockquotes with a callout marker into Callout nodes.
+// The Markdown parser joins blockquotes only separated by an empty line, so a marker at the start
+// of a later paragraph in a blockquote begins a new callout.
+func ProcessCallouts(doc ast.Node) {
+	var quotes []*ast.BlockQuote
+	ast.WalkFunc(doc, func(node ast.Node, entering bool) ast.WalkStatus {
+		if quote, ok := node.(*ast.BlockQuote); ok && entering {
+			quotes = append(quotes, quote)
+		}
+		return ast.GoToNext
+	})
+
+	// Nodes are replaced after the walk, which ranges over the children it visits
+	for _, quote := range quotes {
+		if !slices.ContainsFunc(quote.Children, startsCallout) {
+			continue
+		}
+
+		var nodes []ast.Node
+		var current ast.Node
+		for _, child := range quote.Children {
+			if startsCallout(child) {
+				current = newCallout(child.(*ast.Paragraph))
+				nodes = append(nodes, current)
+				// Nothing may be left of the paragraph after the title
+				if len(child.GetChildren()) == 0 {
+					continue
+				}
+			} else if current == nil {
+				current = &ast.BlockQuote{}
+				nodes = append(nodes, current)
+			}
+			appendChild(current, child)
+		}
+		replaceNode(quote, nodes...)
+	}
+}
+
+// startsCallout reports whether node is a paragraph starting with a callout marker
+func startsCallout(node ast.Node) bool {
+	if _, ok := node.(*ast.Paragraph); !ok || len(node.GetChildren()) == 0 {
+		return false
+	}
+	first, ok := node.GetChildren()[0].(*ast.Text)
+	return ok && calloutRegex.Match(first.Literal)
+}
+
+// newCallout returns the callout started by paragraph. The marker and the rest of its line
+// are moved from the paragraph into the callout's title.
+func newCallout(paragraph *ast.Paragraph) *Callout {
+	first := paragraph.Children[0].(*ast.Text)
+	match := calloutRegex.FindSubmatch(first.Literal)
+	first.Literal = first.Literal[len(match[0]):]
+
+	callout := &Callout{Kind: strings.ToLower(string(match[1])), Foldable: len(match[2]) > 0, Open: string(match[2]) != "-"}
+
+	// The title is everything up to the end of the marker's line
+	titleNodes, rest := paragraph.Children, []ast.Node(nil)
+	for i, node := range paragraph.Children {
+		text, ok := node.(*ast.Text)
+		if !ok {
+			continue
+		}
+		line, after, found := bytes.Cut(text.Literal, []byte("\n"))
+		if !found {
+			continue
+		}
+
+		titleNodes = append(slices.Clone(paragraph.Children[:i]), &ast.Text{Leaf: ast.Leaf{Literal: line}})
+		text.Literal = after
+		rest = paragraph.Children[i:]
+		if len(after) == 0 {
+			rest = rest[1:]
+		}
+		break
+	}
+	paragraph.Children = rest
+
+	title := &CalloutTitle{}
+	if isBlank(titleNodes) {
+		titleNodes = []ast.Node{&ast.Text{Leaf: ast.Leaf{Literal: []byte(defaultCalloutTitle(callout.Kind))}}}
+	}
+	for _, node := range titleNodes {
+		appendChild(title, node)
+	}
+	appendChild(callout, title)
+	return callout
+}
+
+// isBlank reports whether nodes are only whitespace text
+func isBlank(nodes []ast.Node) bool {
+	for _, node := range nodes {
+		text, ok := node.(*ast.Text)
+		if !ok || len(bytes.TrimSpace(text.Literal)) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// defaultCalloutTitle is the title of a callout without one, its kind in title case
+func defaultCalloutTitle(kind string) string {
+	return strings.ToUpper(kind[:1]) + strings.ReplaceAll(kind[1:], "-", " ")
+}
@@ -0,0 +1,50 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/gomarkdown/markdown/ast"
+	"github.com/gomarkdown/markdown/parser"
+)
+
+func TestProcessCallouts(t *testing.T) {
+	source := "> Plain quote\n\n> [!WARNING]- Mind *this*\n> Body text\n>\n> More\n\n> [!tip]\n> Hint\n"
+	doc := parser.NewWithExtensions(parser.CommonExtensions).Parse([]byte(source))
+	ProcessCallouts(doc)
+
+	var callouts []*Callout
+	quotes := 0
+	ast.WalkFunc(doc, func(node ast.Node, entering bool) ast.WalkStatus {
+		switch node := node.(type) {
+		case *Callout:
+			if entering {
+				callouts = append(callouts, node)
+			}
+		case *ast.BlockQuote:
+			if entering {
+				quotes++
+			}
+		}
+		return ast.GoToNext
+	})
+
+	if len(callouts) != 2 || quotes != 1 {
+		t.Fatalf("got %d callouts and %d blockquotes, want 2 and 1", len(callouts), quotes)
+	}
+
+	warning := callouts[0]
+	if warning.Kind != "warning" || !warning.Foldable || warning.Open {
+		t.Errorf("got warning callout %+v", warning)
+	}
+	if title := nodeText(warning.Children[0]); title != "Mind this" {
+		t.Errorf("got warning title %q", title)
+	}
+	if len(warning.Children) != 3 || nodeText(warning.Children[1]) != "Body text" {
+		t.Errorf("got warning content %q", nodeText(warning))
+	}
+
+	tip := callouts[1]
+	if tip.Kind != "tip" || tip.Foldable || nodeText(tip.Children[0]) != "Tip" || nodeText(tip.Children[1]) != "Hint" {
+		t.Errorf("got tip callout %+v with text %q", tip, nodeText(tip))
+	}
+}
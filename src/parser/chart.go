@@ -0,0 +1,252 @@
+package parser
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Chart types supported by the {chart} directive, mapped to their Plotly trace type and mode
+var chartTypes = map[string][2]string{
+	"line":      {"scatter", "lines"},
+	"scatter":   {"scatter", "markers"},
+	"bar":       {"bar", ""},
+	"histogram": {"histogram", ""},
+}
+
+// table holds tabular chart data by column, in the order the columns appear in the file
+type table struct {
+	columns []string
+	values  map[string][]any
+}
+
+// chartDirective renders a Plotly chart from a CSV or JSON data file, resolved relative to the page.
+// Charts are numbered as figures of the page, and a caption is shown below the chart as "Figure N. caption".
+//
+//	{chart data="mydata.csv" type="line" x="year" y="sales,costs" width="600" height="400" title="Sales" caption="Sales per year"}
+func chartDirective(call *Call) (string, error) {
+	ctx := call.Context
+	args := call.Args.Named
+	if data := args["data"]; data != "" && filepath.IsLocal(data) {
+		ctx.AddDependency(filepath.Join(ctx.Dir(), data))
+	}
+
+	figure := ctx.Next("figure")
+	chart, err := renderChart(args, ctx.Dir(), ctx.UniqueID(fmt.Sprintf("chart-%d", ctx.Next("chart"))))
+	if err != nil {
+		return "", err
+	}
+
+	style := ""
+	if width, err := strconv.Atoi(args["width"]); err == nil {
+		style = fmt.Sprintf(` style="max-width: %dpx"`, width)
+	}
+	caption := ""
+	if args["caption"] != "" {
+		caption = fmt.Sprintf(`<figcaption>Figure %d. %s</figcaption>`, figure, html.EscapeString(args["caption"]))
+	}
+	return fmt.Sprintf(`<figure class="chart" id="%s"%s>%s%s</figure>`, ctx.UniqueID(fmt.Sprintf("figure-%d", figure)), style, chart, caption), nil
+}
+
+func renderChart(args map[string]string, dir string, id string) (string, error) {
+	chartType := args["type"]
+	if chartType == "" {
+		chartType = "line"
+	}
+	plotlyType, ok := chartTypes[chartType]
+	if !ok {
+		return "", fmt.Errorf("unknown chart type %q, expected line, bar, scatter or histogram", chartType)
+	}
+
+	data := args["data"]
+	if data == "" {
+		return "", errors.New("missing data attribute")
+	}
+	if !filepath.IsLocal(data) {
+		return "", fmt.Errorf("data file %q must be relative to the page", data)
+	}
+
+	t, err := loadTable(filepath.Join(dir, data))
+	if err != nil {
+		return "", err
+	}
+
+	x := args["x"]
+	if x == "" && len(t.columns) > 0 {
+		x = t.columns[0]
+	}
+	if err := t.check(x, data); err != nil {
+		return "", err
+	}
+
+	var traces []map[string]any
+	if chartType == "histogram" {
+		traces = append(traces, map[string]any{"type": plotlyType[0], "x": t.values[x], "name": x})
+	} else {
+		var ys []string
+		if args["y"] != "" {
+			ys = strings.Split(args["y"], ",")
+		} else if len(t.columns) > 1 {
+			ys = []string{t.columns[1]}
+		} else {
+			return "", fmt.Errorf("no y column in %s", data)
+		}
+
+		for _, y := range ys {
+			y = strings.TrimSpace(y)
+			if err := t.check(y, data); err != nil {
+				return "", err
+			}
+
+			trace := map[string]any{"type": plotlyType[0], "x": t.values[x], "y": t.values[y], "name": y}
+			if plotlyType[1] != "" {
+				trace["mode"] = plotlyType[1]
+			}
+			traces = append(traces, trace)
+		}
+	}
+
+	layout := map[string]any{"title": map[string]any{"text": args["title"]}}
+	if width, err := strconv.Atoi(args["width"]); err == nil {
+		layout["width"] = width
+	}
+	if height, err := strconv.Atoi(args["height"]); err == nil {
+		layout["height"] = height
+	}
+
+	config, err := json.Marshal(map[string]any{"data": traces, "layout": layout})
+	if err != nil {
+		return "", err
+	}
+
+	// Escape "</" so the data can never close the script element early
+	config = bytes.ReplaceAll(config, []byte("</"), []byte(`<\/`))
+
+	return fmt.Sprintf(`<div class="chart-plot" id="%s"></div><script type="application/json" class="chart-config">%s</script>`, id, config), nil
+}
+
+func (t *table) check(column string, file string) error {
+	if _, ok := t.values[column]; !ok {
+		return fmt.Errorf("column %q not found in %s, available columns: %s", column, file, strings.Join(t.columns, ", "))
+	}
+	return nil
+}
+
+// loadTable reads chart data from a CSV file with a header row, or from a JSON
+// file holding either an array of objects or an object of arrays
+func loadTable(path string) (*table, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read data file %s", filepath.Base(path))
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		return csvTable(content)
+	case ".json":
+		return jsonTable(content)
+	default:
+		return nil, fmt.Errorf("unsupported data file %s, expected .csv or .json", filepath.Base(path))
+	}
+}
+
+func csvTable(content []byte) (*table, error) {
+	records, err := csv.NewReader(bytes.NewReader(content)).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, errors.New("empty CSV file")
+	}
+
+	t := &table{columns: records[0], values: make(map[string][]any)}
+	for _, record := range records[1:] {
+		for i, column := range t.columns {
+			var value any
+			if i < len(record) {
+				value = record[i]
+				if number, err := strconv.ParseFloat(record[i], 64); err == nil {
+					value = number
+				}
+			}
+			t.values[column] = append(t.values[column], value)
+		}
+	}
+
+	return t, nil
+}
+
+func jsonTable(content []byte) (*table, error) {
+	t := &table{values: make(map[string][]any)}
+
+	switch trimmed := bytes.TrimSpace(content); {
+	case bytes.HasPrefix(trimmed, []byte("[")):
+		var rows []json.RawMessage
+		if err := json.Unmarshal(trimmed, &rows); err != nil {
+			return nil, err
+		}
+
+		for i, raw := range rows {
+			var row map[string]any
+			if err := json.Unmarshal(raw, &row); err != nil {
+				return nil, fmt.Errorf("row %d is not an object", i+1)
+			}
+			if i == 0 {
+				keys, err := objectKeys(raw)
+				if err != nil {
+					return nil, err
+				}
+				t.columns = keys
+			}
+			for _, column := range t.columns {
+				t.values[column] = append(t.values[column], row[column])
+			}
+		}
+
+	case bytes.HasPrefix(trimmed, []byte("{")):
+		if err := json.Unmarshal(trimmed, &t.values); err != nil {
+			return nil, errors.New("JSON object data must map column names to arrays")
+		}
+		keys, err := objectKeys(trimmed)
+		if err != nil {
+			return nil, err
+		}
+		t.columns = keys
+
+	default:
+		return nil, errors.New("JSON data must be an array of objects or an object of arrays")
+	}
+
+	return t, nil
+}
+
+// objectKeys returns the keys of a JSON object in the order they are written
+func objectKeys(raw []byte) ([]string, error) {
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	if _, err := decoder.Token(); err != nil {
+		return nil, err
+	}
+
+	var keys []string
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, token.(string))
+
+		var value json.RawMessage
+		if err := decoder.Decode(&value); err != nil {
+			return nil, err
+		}
+	}
+
+	return keys, nil
+}
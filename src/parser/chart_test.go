@@ -0,0 +1,117 @@
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gomarkdown/markdown"
+	mdhtml "github.com/gomarkdown/markdown/html"
+	mdparser "github.com/gomarkdown/markdown/parser"
+)
+
+func TestLoadTable(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		columns []string
+		values  map[string][]any
+		err     string // Part of the error, empty if loading succeeds
+	}{
+		{"csv", "data.csv", "year,sales,region\n2020,1.5,north\n2021,2,\n", []string{"year", "sales", "region"},
+			map[string][]any{"year": {2020.0, 2021.0}, "sales": {1.5, 2.0}, "region": {"north", ""}}, ""},
+		{"json rows", "data.json", `[{"year": 2020, "sales": 3}, {"sales": 4, "year": 2021}]`, []string{"year", "sales"},
+			map[string][]any{"year": {2020.0, 2021.0}, "sales": {3.0, 4.0}}, ""},
+		{"json columns", "data.json", `{"year": [2020, 2021], "sales": [3, 4]}`, []string{"year", "sales"},
+			map[string][]any{"year": {2020.0, 2021.0}, "sales": {3.0, 4.0}}, ""},
+		{"empty csv", "data.csv", "", nil, nil, "empty CSV file"},
+		{"short csv row", "data.csv", "a,b\n1\n", nil, nil, "wrong number of fields"},
+		{"invalid csv", "data.csv", "a,b\n\"1,2\n", nil, nil, "quote"},
+		{"json row not an object", "data.json", `[{"a": 1}, 2]`, nil, nil, "row 2 is not an object"},
+		{"json column not an array", "data.json", `{"a": 1}`, nil, nil, "must map column names to arrays"},
+		{"json scalar", "data.json", `"a"`, nil, nil, "array of objects or an object of arrays"},
+		{"unsupported file", "data.txt", "a", nil, nil, "unsupported data file data.txt"},
+		{"missing file", "", "", nil, nil, "could not read data file missing.csv"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "missing.csv")
+			if test.file != "" {
+				path = filepath.Join(filepath.Dir(path), test.file)
+				if err := os.WriteFile(path, []byte(test.content), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			table, err := loadTable(path)
+			if test.err != "" {
+				if err == nil || !strings.Contains(err.Error(), test.err) {
+					t.Fatalf("got error %v, want one about %q", err, test.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(table.columns, test.columns) || !reflect.DeepEqual(table.values, test.values) {
+				t.Fatalf("got columns %q with values %v", table.columns, table.values)
+			}
+		})
+	}
+}
+
+func TestChartDirective(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "sales.csv"), []byte("year,sales,costs\n2020,1,2\n2021,3,4\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		source string
+		want   []string // Parts of the rendered page
+		err    string   // Part of the error reported for the page, empty if there is none
+	}{
+		{"chart", `{chart data="sales.csv" type=bar y="sales,costs" caption="Sales"}`,
+			[]string{`class="chart-plot"`, `"type":"bar"`, `"name":"costs"`, `Figure 1. Sales`}, ""},
+		{"default columns", `{chart data="sales.csv"}`, []string{`"x":[2020,2021],"y":[1,3]`, `"mode":"lines"`}, ""},
+		{"missing file", `{chart data="missing.csv"}`, []string{`alert-error`, `chart error: could not read data file missing.csv`}, "could not read data file"},
+		{"missing x column", `{chart data="sales.csv" x=month}`, []string{`alert-error`}, `column "month" not found in sales.csv, available columns: year, sales, costs`},
+		{"missing y column", `{chart data="sales.csv" y="sales, profit"}`, []string{`alert-error`}, `column "profit" not found`},
+		{"unknown type", `{chart data="sales.csv" type=pie}`, []string{`alert-error`}, `unknown chart type "pie"`},
+		{"no data", `{chart type=bar}`, []string{`alert-error`}, "missing data attribute"},
+		{"outside the page", `{chart data="../sales.csv"}`, []string{`alert-error`}, "must be relative to the page"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := NewRenderContext(filepath.Join(dir, "page.md"))
+			registry := NewRegistry()
+			expanded, directives := registry.Expand(ctx, "Text\n\n"+test.source+"\n", 1)
+			doc := mdparser.NewWithExtensions(mdparser.CommonExtensions).Parse([]byte(expanded))
+			registry.Insert(ctx, doc, directives)
+			page := string(markdown.Render(doc, mdhtml.NewRenderer(mdhtml.RendererOptions{})))
+
+			for _, want := range test.want {
+				if !strings.Contains(page, want) {
+					t.Errorf("page is missing %s:\n%s", want, page)
+				}
+			}
+
+			errs := ctx.Errors()
+			if test.err == "" {
+				if len(errs) > 0 {
+					t.Fatalf("unexpected errors: %v", errs)
+				}
+				return
+			}
+			if len(errs) != 1 || !strings.Contains(errs[0].Error(), test.err) || !strings.HasPrefix(errs[0].Error(), "3:") {
+				t.Fatalf("got errors %v, want one on line 3 about %q", errs, test.err)
+			}
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package parser
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// CodeInfo is the info string of a fenced code block: its language, followed by options
+//
+//	```go {3-5} title="main.go" linenos start=10
+type CodeInfo struct {
+	Language    string
+	Title       string   // Shown above the code, like a file name
+	LineNumbers bool     // Set by linenos, or by start
+	Start       int      // Number of the first line
+	Highlight   [][2]int // Ranges of highlighted lines, by their shown line numbers, from {3-5} or hl=3-5
+	NoHighlight bool     // Show the code without syntax highlighting, set by nohighlight
+}
+
+var lineRangesRegex = regexp.MustCompile(`^\{?[\d,\s-]+\}?$`)
+
+// ParseCodeInfo parses the info string of a fenced code block.
+// Invalid options are reported and ignored, the rest of the info string still applies.
+func ParseCodeInfo(info string) (CodeInfo, []error) {
+	code := CodeInfo{Start: 1}
+	var errs []error
+
+	// The Markdown parser takes braces at the start of an info string as all of it,
+	// and a fence with anything after them is not a code block
+	if rest, ok := strings.CutPrefix(strings.TrimSpace(info), "{"); ok {
+		if ranges, after, closed := strings.Cut(rest, "}"); closed && strings.TrimSpace(after) != "" {
+			errs = append(errs, fmt.Errorf("{%s} must come after the language or be the whole info string", ranges))
+		}
+	}
+
+	args := ParseArgs(info)
+	for i, word := range args.Positional {
+		switch {
+		case lineRangesRegex.MatchString(word):
+			ranges, err := parseLineRanges(word)
+			if err != nil {
+				errs = append(errs, err)
+			}
+			code.Highlight = append(code.Highlight, ranges...)
+		case word == "linenos":
+			code.LineNumbers = true
+		case word == "nohighlight":
+			code.NoHighlight = true
+		case i == 0:
+			code.Language = word
+		default:
+			errs = append(errs, fmt.Errorf("unknown code block option %q", word))
+		}
+	}
+
+	for key, value := range args.Named {
+		switch key {
+		case "title", "filename":
+			code.Title = value
+		case "hl", "highlight":
+			ranges, err := parseLineRanges(value)
+			if err != nil {
+				errs = append(errs, err)
+			}
+			code.Highlight = append(code.Highlight, ranges...)
+		case "start":
+			start, err := strconv.Atoi(value)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("invalid start line %q", value))
+				continue
+			}
+			code.Start = start
+			code.LineNumbers = true
+		case "linenos":
+			code.LineNumbers = value != "false"
+		default:
+			errs = append(errs, fmt.Errorf("unknown code block option %q", key))
+		}
+	}
+
+	return code, errs
+}
+
+// parseLineRanges parses line numbers and ranges like {1,3-5}
+func parseLineRanges(s string) ([][2]int, error) {
+	var ranges [][2]int
+	for _, part := range strings.Split(strings.Trim(s, "{}"), ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		from, to, isRange := strings.Cut(part, "-")
+		start, err := strconv.Atoi(strings.TrimSpace(from))
+		end := start
+		if err == nil && isRange {
+			end, err = strconv.Atoi(strings.TrimSpace(to))
+		}
+		if err != nil || end < start {
+			return ranges, fmt.Errorf("invalid line range %q", part)
+		}
+		ranges = append(ranges, [2]int{start, end})
+	}
+	return ranges, nil
+}
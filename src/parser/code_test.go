@@ -0,0 +1,35 @@
+package parser
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseCodeInfo(t *testing.T) {
+	tests := []struct {
+		info   string
+		want   CodeInfo
+		errors int
+	}{
+		{"", CodeInfo{Start: 1}, 0},
+		{"go", CodeInfo{Language: "go", Start: 1}, 0},
+		{`go {3-5} title="main.go"`, CodeInfo{Language: "go", Title: "main.go", Start: 1, Highlight: [][2]int{{3, 5}}}, 0},
+		{"3-5", CodeInfo{Start: 1, Highlight: [][2]int{{3, 5}}}, 0},
+		{"python {1,4-6} linenos", CodeInfo{Language: "python", LineNumbers: true, Start: 1, Highlight: [][2]int{{1, 1}, {4, 6}}}, 0},
+		{"go start=10 hl=11", CodeInfo{Language: "go", LineNumbers: true, Start: 10, Highlight: [][2]int{{11, 11}}}, 0},
+		{"text nohighlight filename=out.txt", CodeInfo{Language: "text", Title: "out.txt", Start: 1, NoHighlight: true}, 0},
+		{"go {5-3} start=x wrap", CodeInfo{Language: "go", Start: 1}, 3},
+		{"{2}", CodeInfo{Start: 1, Highlight: [][2]int{{2, 2}}}, 0},
+		{`{2} title="x.go"`, CodeInfo{Title: "x.go", Start: 1, Highlight: [][2]int{{2, 2}}}, 1},
+	}
+
+	for _, test := range tests {
+		got, errs := ParseCodeInfo(test.info)
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%q: got %+v, want %+v", test.info, got, test.want)
+		}
+		if len(errs) != test.errors {
+			t.Errorf("%q: got errors %v, want %d", test.info, errs, test.errors)
+		}
+	}
+}
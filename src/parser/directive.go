@@ -0,0 +1,193 @@
+package parser
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/gomarkdown/markdown/ast"
+)
+
+// Directive is an inline {name args} directive, or a {name args}...{/name} block directive
+//
+//	{sidenote This is a sidenote}
+//	{sidenote: This is also a sidenote}
+//	{sidenote}
+//	A block sidenote, which can contain {footnote: other directives}
+//	{/sidenote}
+type Directive struct {
+	Name    string
+	Args    string    // Everything after the name, or after the colon in the {name: args} form
+	Colon   bool      // Written as {name: args}
+	Block   bool      // Closed by a matching {/name}
+	Content []Segment // Content between the opening and closing tags of a block directive
+	Inner   string    // Source text of the content of a block directive
+	Line    int
+	Col     int
+
+	open  string // Source text of the opening tag
+	close string // Source text of the closing tag
+}
+
+// Segment is either literal Markdown text or a directive
+type Segment struct {
+	Text      string
+	Directive *Directive
+}
+
+// Source returns the directive as it was written in the Markdown source
+func (d *Directive) Source() string {
+	if !d.Block {
+		return d.open
+	}
+	return d.open + d.Inner + d.close
+}
+
+// ParseDirectives splits Markdown source into literal text and directives.
+// An opening tag becomes a block directive when a matching closing tag follows it at the same
+// nesting level, otherwise it is an inline directive. Closing tags without a matching opening tag
+// are reported as errors and kept as literal text.
+func ParseDirectives(source string) ([]Segment, []error) {
+	l := newLexer(source)
+	tokens := l.scanTokens()
+	errs := l.errors
+
+	// Pair closing tags with the nearest open tag of the same name
+	pairs := make(map[int]int)
+	var open []int
+	for i, token := range tokens {
+		switch token.typ {
+		case directiveOpen:
+			if !token.colon {
+				open = append(open, i)
+			}
+		case directiveClose:
+			matched := false
+			for j := len(open) - 1; j >= 0; j-- {
+				if tokens[open[j]].name == token.name {
+					pairs[open[j]] = i
+					open = open[:j]
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				line, col := l.position(token.pos)
+				errs = append(errs, &SyntaxError{Line: line, Col: col, Msg: fmt.Sprintf("{/%s} without a matching {%s}", token.name, token.name)})
+			}
+		}
+	}
+
+	segments := buildSegments(l, tokens, 0, len(tokens)-1, pairs)
+	return segments, errs
+}
+
+// buildSegments turns tokens[start:end] into segments, where pairs maps the opening tag of each block directive to its closing tag
+func buildSegments(l *Lexer, tokens []Token, start int, end int, pairs map[int]int) []Segment {
+	var segments []Segment
+	addText := func(text string) {
+		if n := len(segments); n > 0 && segments[n-1].Directive == nil {
+			segments[n-1].Text += text
+			return
+		}
+		segments = append(segments, Segment{Text: text})
+	}
+
+	i := start
+	for i < end {
+		token := tokens[i]
+		switch token.typ {
+		case directiveOpen:
+			line, col := l.position(token.pos)
+			d := &Directive{
+				Name:  token.name,
+				Args:  token.args,
+				Colon: token.colon,
+				Line:  line,
+				Col:   col,
+				open:  token.val,
+			}
+
+			if closeIndex, ok := pairs[i]; ok {
+				closeToken := tokens[closeIndex]
+				d.Block = true
+				d.Content = buildSegments(l, tokens, i+1, closeIndex, pairs)
+				d.Inner = l.input[token.pos+len(token.val) : closeToken.pos]
+				d.close = closeToken.val
+				i = closeIndex
+			}
+
+			segments = append(segments, Segment{Directive: d})
+		default:
+			addText(token.val)
+		}
+		i++
+	}
+
+	return segments
+}
+
+// placeholder marks where a directive was in the Markdown source. It is an HTML comment,
+// which the Markdown parser keeps as an inline or block HTML node in the document.
+func placeholder(i int) string {
+	return fmt.Sprintf("<!--directive:%d-->", i)
+}
+
+var placeholderRegex = regexp.MustCompile(`^\s*<!--directive:(\d+)-->\s*$`)
+
+var codePlaceholderRegex = regexp.MustCompile(`<!--directive:(\d+)-->`)
+
+// InsertDirectives replaces the placeholders left by Registry.Expand with the HTML returned by render,
+// in document order. Render receives the placeholder node, so it can inspect where in the document
+// the directive is, such as the paragraph containing it.
+//
+// The lexer only knows fenced code blocks, so directives in indented code blocks get placeholders too.
+// Those are put back as they were written, since code is never rendered.
+func InsertDirectives(doc ast.Node, directives []*Directive, render func(d *Directive, node ast.Node) string) {
+	ast.WalkFunc(doc, func(node ast.Node, entering bool) ast.WalkStatus {
+		var leaf *ast.Leaf
+		switch node := node.(type) {
+		case *ast.HTMLSpan:
+			leaf = &node.Leaf
+		case *ast.HTMLBlock:
+			leaf = &node.Leaf
+		case *ast.CodeBlock:
+			node.Literal = codePlaceholderRegex.ReplaceAllFunc(node.Literal, func(match []byte) []byte {
+				i, err := strconv.Atoi(string(codePlaceholderRegex.FindSubmatch(match)[1]))
+				if err != nil || i >= len(directives) {
+					return match
+				}
+				return []byte(directives[i].Source())
+			})
+			return ast.GoToNext
+		default:
+			return ast.GoToNext
+		}
+
+		match := placeholderRegex.FindSubmatch(leaf.Literal)
+		if match == nil {
+			return ast.GoToNext
+		}
+		i, err := strconv.Atoi(string(match[1]))
+		if err != nil || i >= len(directives) {
+			return ast.GoToNext
+		}
+
+		leaf.Literal = []byte(render(directives[i], node))
+		return ast.GoToNext
+	})
+}
+
+// SourceOf joins segments back into the Markdown source they were parsed from
+func SourceOf(segments []Segment) string {
+	var sb strings.Builder
+	for _, segment := range segments {
+		if segment.Directive != nil {
+			sb.WriteString(segment.Directive.Source())
+		} else {
+			sb.WriteString(segment.Text)
+		}
+	}
+	return sb.String()
+}
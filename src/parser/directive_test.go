@@ -0,0 +1,125 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/gomarkdown/markdown"
+	mdhtml "github.com/gomarkdown/markdown/html"
+	mdparser "github.com/gomarkdown/markdown/parser"
+)
+
+func TestParseDirectives(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		want   []string // Names of the top level directives, with a slash suffix for block directives
+	}{
+		{"inline", "a {sidenote note} b", []string{"sidenote"}},
+		{"colon", "a {sidenote: note} b", []string{"sidenote"}},
+		{"block", "{sidenote}\nnote\n{/sidenote}", []string{"sidenote/"}},
+		{"nested braces", `{sidenote $\frac{a}{b}$ and {x}}`, []string{"sidenote"}},
+		{"nested block", "{note}{sidenote}a{/sidenote}{/note}", []string{"note/"}},
+		{"escaped", `\{sidenote note}`, nil},
+		{"code span", "`{sidenote note}`", nil},
+		{"code span over lines", "`a\n{sidenote note}`", nil},
+		{"unclosed code span", "Type a single ` to start code.\n\n{sidenote a note}\n\nLater `code`.", []string{"sidenote"}},
+		{"fenced code", "```\n{sidenote}\nnote\n{/sidenote}\n```\n", nil},
+		{"math", `$\{a\} {b}$ and $${c}$$`, nil},
+		{"not a directive", "{a,b} { x }", nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			segments, errs := ParseDirectives(test.source)
+			if len(errs) > 0 {
+				t.Fatalf("unexpected errors: %v", errs)
+			}
+
+			var got []string
+			for _, segment := range segments {
+				if d := segment.Directive; d != nil {
+					name := d.Name
+					if d.Block {
+						name += "/"
+					}
+					got = append(got, name)
+				}
+			}
+
+			if len(got) != len(test.want) {
+				t.Fatalf("got directives %v, want %v", got, test.want)
+			}
+			for i := range got {
+				if got[i] != test.want[i] {
+					t.Fatalf("got directives %v, want %v", got, test.want)
+				}
+			}
+
+			if source := SourceOf(segments); source != test.source {
+				t.Fatalf("source not preserved, got %q", source)
+			}
+		})
+	}
+}
+
+func TestInsertDirectivesInIndentedCode(t *testing.T) {
+	ctx := NewRenderContext("page.md")
+	registry := NewRegistry()
+	expanded, directives := registry.Expand(ctx, "Text\n\n    indented {sidenote code}\n\nAnd {sidenote a note}\n", 1)
+	doc := mdparser.NewWithExtensions(mdparser.CommonExtensions).Parse([]byte(expanded))
+	registry.Insert(ctx, doc, directives)
+	page := string(markdown.Render(doc, mdhtml.NewRenderer(mdhtml.RendererOptions{})))
+
+	if !strings.Contains(page, "<code>indented {sidenote code}\n</code>") || strings.Contains(page, "directive:") {
+		t.Fatalf("directive in indented code is not kept as written:\n%s", page)
+	}
+	if !strings.Contains(page, "a note") || len(ctx.Errors()) > 0 {
+		t.Fatalf("directive after the code is not rendered, errors %v:\n%s", ctx.Errors(), page)
+	}
+}
+
+func TestParseDirectivesErrors(t *testing.T) {
+	_, errs := ParseDirectives("line one\nsome {sidenote without end\nand {/note}")
+	if len(errs) != 2 {
+		t.Fatalf("expected 2 errors, got %v", errs)
+	}
+
+	want := []string{
+		"2:6: unterminated {sidenote} directive",
+		"3:5: {/note} without a matching {note}",
+	}
+	for i, err := range errs {
+		if err.Error() != want[i] {
+			t.Errorf("got error %q, want %q", err, want[i])
+		}
+	}
+}
+
+func TestParseDirectivesCodeInfoErrors(t *testing.T) {
+	_, errs := ParseDirectives("Text\n\n```go linenos wrap\nfmt.Println()\n```\n\n  ~~~  python\n~~~\n\n```{2} title=\"x.go\"\na\n```\n")
+	if len(errs) != 2 {
+		t.Fatalf("expected 2 errors, got %v", errs)
+	}
+
+	want := []string{
+		`3:4: code block info string: unknown code block option "wrap"`,
+		`10:4: code block info string: {2} must come after the language or be the whole info string`,
+	}
+	for i, err := range errs {
+		if err.Error() != want[i] {
+			t.Errorf("got error %q, want %q", err, want[i])
+		}
+	}
+}
+
+func TestParseArgs(t *testing.T) {
+	args := ParseArgs(`data="my data.csv" type=bar wide "a title"`)
+
+	if args.Get("data") != "my data.csv" || args.Get("type") != "bar" {
+		t.Errorf("got named arguments %v", args.Named)
+	}
+	if len(args.Positional) != 2 || args.Positional[0] != "wide" || args.Positional[1] != "a title" {
+		t.Errorf("got positional arguments %q", args.Positional)
+	}
+}
@@ -0,0 +1,185 @@
+package parser
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gomarkdown/markdown/ast"
+	mdparser "github.com/gomarkdown/markdown/parser"
+)
+
+// footnoteRefs numbers the footnote references of a document, covering both inline ^[text] footnotes
+// and [^label] references. The document must be parsed with the Footnotes extension for footnotes to be found.
+//
+// Numbers are taken from the page's context as the directives of the document are rendered, so the
+// footnotes of fragments, like those in sidenotes, are numbered in document order with the page's own.
+// The notes are collected in the context, and listed once at the end of the page by listFootnotes.
+type footnoteRefs struct {
+	refs      []*ast.Link
+	positions map[ast.Node]int // Document order of every node
+	labels    map[int]int      // Numbers by the NoteID the Markdown parser gave a footnote
+	next      int              // Index of the first reference without a number
+}
+
+func newFootnoteRefs(doc ast.Node) *footnoteRefs {
+	f := &footnoteRefs{positions: make(map[ast.Node]int), labels: make(map[int]int)}
+	ast.WalkFunc(doc, func(node ast.Node, entering bool) ast.WalkStatus {
+		if !entering {
+			return ast.GoToNext
+		}
+		f.positions[node] = len(f.positions)
+		switch node := node.(type) {
+		case *ast.List:
+			if node.IsFootnotesList {
+				return ast.SkipChildren
+			}
+		case *ast.Link:
+			if node.NoteID != 0 && node.Footnote != nil {
+				f.refs = append(f.refs, node)
+			}
+		}
+		return ast.GoToNext
+	})
+	return f
+}
+
+// numberBefore numbers the references before node in the document, or all remaining references if node is nil.
+// The Markdown parser gives repeated references to a label the same NoteID, so those share a number.
+func (f *footnoteRefs) numberBefore(ctx *RenderContext, node ast.Node) {
+	for ; f.next < len(f.refs); f.next++ {
+		ref := f.refs[f.next]
+		if node != nil && f.positions[ref] > f.positions[node] {
+			return
+		}
+
+		number, ok := f.labels[ref.NoteID]
+		if !ok {
+			number = ctx.Next("footnote")
+			f.labels[ref.NoteID] = number
+			ctx.footnotes = append(ctx.footnotes, footnote{number: number, note: ref.Footnote.(*ast.ListItem)})
+		}
+		ref.NoteID = number
+		ref.Destination = []byte(strconv.Itoa(number))
+	}
+}
+
+// footnote is a numbered note of a page, from the page itself or from one of its fragments
+type footnote struct {
+	number int
+	note   *ast.ListItem
+}
+
+// listFootnotes drops the list of footnotes built by the Markdown parser. The page's notes are listed
+// in a single list at the end of the page instead, which is added once the page's own directives are rendered.
+func listFootnotes(ctx *RenderContext, doc ast.Node) {
+	var parsedLists []ast.Node
+	for _, child := range doc.GetChildren() {
+		switch child := child.(type) {
+		case *ast.Footnotes:
+			parsedLists = append(parsedLists, child)
+		case *ast.List:
+			if child.IsFootnotesList {
+				parsedLists = append(parsedLists, child)
+			}
+		}
+	}
+	for _, node := range parsedLists {
+		ast.RemoveFromTree(node)
+	}
+
+	if doc != ctx.Doc || len(ctx.footnotes) == 0 {
+		return
+	}
+
+	notes := slices.SortedFunc(slices.Values(ctx.footnotes), func(a, b footnote) int {
+		return a.number - b.number
+	})
+	list := &ast.List{
+		IsFootnotesList: true,
+		ListFlags:       ast.ListTypeOrdered,
+	}
+	if notes[0].number > 1 {
+		list.Start = notes[0].number
+	}
+	for i, footnote := range notes {
+		item := footnote.note
+		item.RefLink = []byte(strconv.Itoa(footnote.number))
+		item.ListFlags = ast.ListTypeOrdered
+		if i == 0 {
+			item.ListFlags |= ast.ListItemBeginningOfList
+		}
+		appendChild(list, item)
+	}
+
+	ast.AppendChild(doc, &ast.Footnotes{})
+	ast.AppendChild(doc, list)
+}
+
+// InlineFootnotes gives the inline ^[text] footnotes of a document parsed with Parser.Inline their notes,
+// and lists them at the end of the document like Parser.Parse does, so their directives are rendered.
+func InlineFootnotes(p *mdparser.Parser, doc ast.Node) {
+	var notes []*ast.ListItem
+	ast.WalkFunc(doc, func(node ast.Node, entering bool) ast.WalkStatus {
+		if link, ok := node.(*ast.Link); ok && entering && link.NoteID != 0 && link.DeferredID == nil {
+			if note, ok := link.Footnote.(*ast.ListItem); ok && len(note.Children) == 0 {
+				p.Inline(note, link.Title)
+				notes = append(notes, note)
+			}
+		}
+		return ast.GoToNext
+	})
+	if len(notes) == 0 {
+		return
+	}
+
+	list := &ast.List{IsFootnotesList: true, ListFlags: ast.ListTypeOrdered}
+	for _, note := range notes {
+		appendChild(list, note)
+	}
+	ast.AppendChild(doc, list)
+}
+
+// footnoteDirective turns {footnote: text} into an inline Markdown footnote,
+// so it is numbered together with [^label] footnotes
+func footnoteDirective(call *Call) (string, error) {
+	content := call.Args.Raw
+	if call.Block {
+		content = call.Content
+	}
+	return "^[" + footnoteText(strings.TrimSpace(content)) + "]", nil
+}
+
+// footnoteText escapes the brackets in the text of an inline footnote that would end it early or keep it
+// from ending. The Markdown parser finds the end of ^[text] by counting brackets, skipping any character after
+// a backslash, so only brackets without a match are escaped and links in the text keep working.
+// A backslash at the end of the text would escape the closing bracket, so a space follows it.
+func footnoteText(text string) string {
+	var sb strings.Builder
+	var open []int // Offsets in sb of the opening brackets without a match yet
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case i > 0 && text[i-1] == '\\':
+			// Escaped, or taken to be by the parser
+		case c == '[':
+			open = append(open, sb.Len())
+		case c == ']':
+			if len(open) == 0 {
+				sb.WriteByte('\\')
+			} else {
+				open = open[:len(open)-1]
+			}
+		}
+		sb.WriteByte(c)
+	}
+
+	escaped := sb.String()
+	for i := len(open) - 1; i >= 0; i-- {
+		escaped = escaped[:open[i]] + "\\" + escaped[open[i]:]
+	}
+	if strings.HasSuffix(escaped, "\\") {
+		escaped += " "
+	}
+	return escaped
+}
@@ -0,0 +1,42 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/gomarkdown/markdown"
+	mdhtml "github.com/gomarkdown/markdown/html"
+	mdparser "github.com/gomarkdown/markdown/parser"
+)
+
+func TestFootnoteDirective(t *testing.T) {
+	tests := []struct {
+		source string
+		note   string // Rendered note
+	}{
+		{"{footnote: a note}", "a note"},
+		{"{footnote: a ] bracket}", "a ] bracket"},
+		{"{footnote: a [ bracket}", "a [ bracket"},
+		{"{footnote: ] and [ backwards}", "] and [ backwards"},
+		{"{footnote: see [the docs](https://example.com) [sic]}", `see <a href="https://example.com">the docs</a> [sic]`},
+		{`{footnote: kept \] escaped}`, "kept ] escaped"},
+		{`{footnote: C:\ }`, `C:\ `}, // The space keeps the backslash from escaping the closing bracket
+		{"{footnote}\nA block ] note\n{/footnote}", "A block ] note"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.source, func(t *testing.T) {
+			ctx := NewRenderContext("page.md")
+			registry := NewRegistry()
+			expanded, directives := registry.Expand(ctx, "Text"+test.source+" after.\n", 1)
+			doc := mdparser.NewWithExtensions(mdparser.CommonExtensions | mdparser.Footnotes).Parse([]byte(expanded))
+			registry.Insert(ctx, doc, directives)
+			page := string(markdown.Render(doc, mdhtml.NewRenderer(mdhtml.RendererOptions{})))
+
+			paragraph := `<p>Text<sup class="footnote-ref" id="fnref:1"><a href="#fn:1">1</a></sup> after.</p>`
+			if !strings.Contains(page, paragraph) || !strings.Contains(page, `<li id="fn:1">`+test.note+"</li>") {
+				t.Fatalf("want the note %q:\n%s", test.note, page)
+			}
+		})
+	}
+}
@@ -0,0 +1,317 @@
+package parser
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+type TokenType int
+
+const (
+	literal        TokenType = iota
+	directiveOpen            // {name args} or {name: args}
+	directiveClose           // {/name}
+	eof
+)
+
+type Token struct {
+	typ   TokenType
+	val   string // Source text of the token
+	pos   int    // Byte offset of the token in the input
+	name  string // Directive name
+	args  string // Directive arguments, with surrounding whitespace removed
+	colon bool   // Directive was written as {name: args}
+}
+
+// SyntaxError is an error in the directives of a Markdown source
+type SyntaxError struct {
+	Line int
+	Col  int
+	Msg  string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("%d:%d: %s", e.Line, e.Col, e.Msg)
+}
+
+// Lexer splits Markdown source into literal text and directive tokens.
+// Code spans, fenced code blocks and math are always literal, and a backslash
+// escapes the character after it, so \{ never starts a directive.
+type Lexer struct {
+	start   int
+	current int
+	input   string
+	tokens  []Token
+	errors  []error
+}
+
+func (l *Lexer) isAtEnd() bool {
+	return l.current >= len(l.input)
+}
+
+func (l *Lexer) scanTokens() []Token {
+	for !l.isAtEnd() {
+		l.start = l.current
+		l.scanToken()
+	}
+
+	l.tokens = append(l.tokens, Token{typ: eof, pos: len(l.input)})
+	return l.tokens
+}
+
+func (l *Lexer) scanToken() {
+	if l.atLineStart() && l.fencedCode() {
+		l.addLiteral()
+		return
+	}
+
+	c := l.advance()
+	switch c {
+	case '\\':
+		// Escaped character, kept as is for the Markdown parser to unescape
+		if !l.isAtEnd() {
+			l.advance()
+		}
+		l.addLiteral()
+	case '`':
+		l.codeSpan()
+		l.addLiteral()
+	case '$':
+		l.math()
+		l.addLiteral()
+	case '{':
+		l.directive()
+	case '\n':
+		l.addLiteral()
+	default:
+		for !l.isAtEnd() && !strings.ContainsRune("\\`${\n", rune(l.peek())) {
+			l.advance()
+		}
+		l.addLiteral()
+	}
+}
+
+// fencedCode consumes a fenced code block starting at the current line and reports problems with its info string
+func (l *Lexer) fencedCode() bool {
+	line := l.restOfLine()
+	indent := len(line) - len(strings.TrimLeft(line, " "))
+	if indent > 3 {
+		return false
+	}
+
+	fence := line[indent:]
+	fenceChar := fence[0:min(1, len(fence))]
+	if fenceChar != "`" && fenceChar != "~" {
+		return false
+	}
+	fenceLen := len(fence) - len(strings.TrimLeft(fence, fenceChar))
+	if fenceLen < 3 {
+		return false
+	}
+
+	info := strings.TrimRight(fence[fenceLen:], " \t\r\n")
+	if _, errs := ParseCodeInfo(info); len(errs) > 0 {
+		infoStart := l.current + indent + fenceLen + len(info) - len(strings.TrimLeft(info, " \t"))
+		line, col := l.position(infoStart)
+		for _, err := range errs {
+			l.errors = append(l.errors, &SyntaxError{Line: line, Col: col, Msg: fmt.Sprintf("code block info string: %v", err)})
+		}
+	}
+
+	closing := strings.Repeat(fenceChar, fenceLen)
+	l.current += len(line)
+	for !l.isAtEnd() {
+		line := l.restOfLine()
+		l.current += len(line)
+		if strings.HasPrefix(strings.TrimSpace(line), closing) {
+			break
+		}
+	}
+	return true
+}
+
+var blankLineRegex = regexp.MustCompile(`\n[ \t]*(\r?\n|$)`)
+
+// codeSpan consumes an inline code span, after its first backtick.
+// A code span can not continue past the end of its paragraph, which is a blank line.
+func (l *Lexer) codeSpan() {
+	ticks := 1
+	for l.accept('`') {
+		ticks++
+	}
+
+	closing := strings.Repeat("`", ticks)
+	rest := l.input[l.current:]
+	if blank := blankLineRegex.FindStringIndex(rest); blank != nil {
+		rest = rest[:blank[0]]
+	}
+	for offset := 0; ; {
+		i := strings.Index(rest[offset:], closing)
+		if i < 0 {
+			return // Unclosed, the backticks are literal
+		}
+		end := offset + i + ticks
+		if end < len(rest) && rest[end] == '`' {
+			// Longer run of backticks, does not close this span
+			offset = end + len(rest[end:]) - len(strings.TrimLeft(rest[end:], "`"))
+			continue
+		}
+		l.current += end
+		return
+	}
+}
+
+// math consumes $...$ or $$...$$ math, after its first dollar sign, following the same rules as the Markdown parser
+func (l *Lexer) math() {
+	if n, _ := mathLength(l.input[l.start:]); n > 0 {
+		l.current = l.start + n
+	}
+}
+
+// directive consumes {name}, {name args}, {name: args} or {/name}, after the opening brace
+func (l *Lexer) directive() {
+	closing := l.accept('/')
+	name := l.name()
+	if name == "" {
+		l.addLiteral()
+		return
+	}
+
+	if closing {
+		if !l.accept('}') {
+			l.addLiteral()
+			return
+		}
+		l.tokens = append(l.tokens, Token{typ: directiveClose, val: l.value(), pos: l.start, name: name})
+		return
+	}
+
+	colon := l.accept(':')
+	if !colon && l.peek() != '}' && l.peek() != ' ' && l.peek() != '\t' && l.peek() != '\n' {
+		// Not a directive, like {a,b}
+		l.addLiteral()
+		return
+	}
+
+	argsStart := l.current
+	if !l.balanced() {
+		line, col := l.position(l.start)
+		l.errors = append(l.errors, &SyntaxError{Line: line, Col: col, Msg: fmt.Sprintf("unterminated {%s} directive", name)})
+
+		// Treat the brace as literal text and continue right after it
+		l.current = l.start + 1
+		l.addLiteral()
+		return
+	}
+
+	args := strings.TrimSpace(l.input[argsStart : l.current-1])
+	l.tokens = append(l.tokens, Token{typ: directiveOpen, val: l.value(), pos: l.start, name: name, args: args, colon: colon})
+}
+
+// balanced consumes input up to and including the brace closing the current directive,
+// skipping escaped characters, code spans and nested pairs of braces
+func (l *Lexer) balanced() bool {
+	depth := 0
+	for !l.isAtEnd() {
+		switch l.advance() {
+		case '\\':
+			if !l.isAtEnd() {
+				l.advance()
+			}
+		case '`':
+			l.codeSpan()
+		case '{':
+			depth++
+		case '}':
+			if depth == 0 {
+				return true
+			}
+			depth--
+		}
+	}
+	return false
+}
+
+func (l *Lexer) name() string {
+	start := l.current
+	for !l.isAtEnd() {
+		c := l.peek()
+		isLetter := c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+		isDigit := c >= '0' && c <= '9'
+		if !isLetter && (l.current == start || !isDigit && c != '-' && c != '_') {
+			break
+		}
+		l.advance()
+	}
+	return l.input[start:l.current]
+}
+
+// addLiteral adds the current lexeme as literal text, merged with a preceding literal
+func (l *Lexer) addLiteral() {
+	if n := len(l.tokens); n > 0 && l.tokens[n-1].typ == literal {
+		l.tokens[n-1].val += l.value()
+		return
+	}
+	l.tokens = append(l.tokens, Token{typ: literal, val: l.value(), pos: l.start})
+}
+
+func (l *Lexer) atLineStart() bool {
+	return l.current == 0 || l.input[l.current-1] == '\n'
+}
+
+// restOfLine returns the input from the current position up to and including the next newline
+func (l *Lexer) restOfLine() string {
+	rest := l.input[l.current:]
+	if i := strings.IndexByte(rest, '\n'); i >= 0 {
+		return rest[:i+1]
+	}
+	return rest
+}
+
+// position returns the 1-based line and column of a byte offset in the input
+func (l *Lexer) position(offset int) (int, int) {
+	before := l.input[:offset]
+	line := strings.Count(before, "\n") + 1
+	col := offset - strings.LastIndexByte(before, '\n')
+	return line, col
+}
+
+func (l *Lexer) advance() byte {
+	c := l.input[l.current]
+	l.current++
+	return c
+}
+
+func (l *Lexer) peek() byte {
+	if l.isAtEnd() {
+		return 0
+	}
+
+	return l.input[l.current]
+}
+
+func (l *Lexer) accept(expected byte) bool {
+	if l.isAtEnd() {
+		return false
+	}
+	if l.input[l.current] != expected {
+		return false
+	}
+
+	l.current++
+	return true
+}
+
+func (l *Lexer) value() string {
+	return l.input[l.start:l.current]
+}
+
+func newLexer(input string) *Lexer {
+	return &Lexer{
+		start:   0,
+		current: 0,
+		input:   input,
+		tokens:  make([]Token, 0),
+	}
+}
@@ -0,0 +1,91 @@
+package parser
+
+import (
+	"strings"
+
+	"github.com/gomarkdown/markdown/ast"
+)
+
+// PageLink is a link in a document to a page or file, with the text around it
+type PageLink struct {
+	Destination string // URL of a Markdown link, empty for wiki links
+	Target      string // Page named by a wiki link, see WikiLink
+	Fragment    string // Heading named by a wiki link
+	Context     string // Text of the block the link is in, shortened around the link
+}
+
+// contextLength is the number of characters of context kept on each side of a link
+const contextLength = 80
+
+// PageLinks returns the Markdown links and unresolved wiki links of a document, in order.
+// Footnote references are not links to pages and are left out.
+func PageLinks(doc ast.Node) []PageLink {
+	var links []PageLink
+	ast.WalkFunc(doc, func(node ast.Node, entering bool) ast.WalkStatus {
+		if !entering {
+			return ast.GoToNext
+		}
+
+		switch node := node.(type) {
+		case *ast.Link:
+			if node.NoteID != 0 || node.Footnote != nil {
+				break
+			}
+			links = append(links, PageLink{Destination: string(node.Destination), Context: linkContext(node, nodeText(node))})
+		case *WikiLink:
+			links = append(links, PageLink{Target: node.Target, Fragment: node.Fragment, Context: linkContext(node, node.Label)})
+		}
+		return ast.GoToNext
+	})
+	return links
+}
+
+// linkContext returns the text of the block around a link, cut to contextLength characters on each side of its text
+func linkContext(link ast.Node, text string) string {
+	block := link.GetParent()
+	for n := block; n != nil; n = n.GetParent() {
+		switch n.(type) {
+		case *ast.Paragraph, *ast.Heading, *ast.TableCell:
+			block = n
+		default:
+			continue
+		}
+		break
+	}
+
+	// Wiki links have no text nodes, only a label
+	var sb strings.Builder
+	ast.WalkFunc(block, func(n ast.Node, entering bool) ast.WalkStatus {
+		switch n := n.(type) {
+		case *WikiLink:
+			sb.WriteString(n.Label)
+		case *ast.Text:
+			sb.Write(n.Literal)
+		case *ast.Code:
+			sb.Write(n.Literal)
+		}
+		return ast.GoToNext
+	})
+	context := strings.Join(strings.Fields(sb.String()), " ")
+	text = strings.Join(strings.Fields(text), " ")
+
+	// Cut by characters rather than bytes
+	runes := []rune(context)
+	start, end := 0, len(runes)
+	if i := strings.Index(context, text); i >= 0 {
+		at := len([]rune(context[:i]))
+		start = max(at-contextLength, 0)
+		end = min(at+len([]rune(text))+contextLength, len(runes))
+	} else {
+		end = min(2*contextLength, len(runes))
+	}
+
+	snippet := strings.TrimSpace(string(runes[start:end]))
+	if start > 0 {
+		snippet = "…" + snippet
+	}
+	if end < len(runes) {
+		snippet += "…"
+	}
+	return snippet
+}
@@ -0,0 +1,131 @@
+package parser
+
+import (
+	"bytes"
+
+	"github.com/gomarkdown/markdown/ast"
+	mdparser "github.com/gomarkdown/markdown/parser"
+)
+
+// DisplayMath is $$...$$ math written inside a paragraph. A paragraph that is only
+// display math becomes an ast.MathBlock instead, and $...$ becomes an ast.Math.
+type DisplayMath struct {
+	ast.Leaf
+}
+
+// RegisterMath adds TeX math to a Markdown parser, replacing its MathJax extension.
+//
+// Inline math is written as $...$, where the opening dollar sign must be followed by a non-space
+// and the closing one preceded by a non-space and not followed by a digit, so prices like
+// $5 and $10 stay text. Display math is written as $$...$$. A backslash escapes a dollar sign,
+// and code spans and code blocks are never parsed as math, nor part of it.
+func RegisterMath(p *mdparser.Parser) {
+	p.RegisterInline('$', inlineMath)
+
+	hook := p.Opts.ParserHook
+	p.Opts.ParserHook = func(data []byte) (ast.Node, []byte, int) {
+		if node, consumed := blockMath(data); consumed > 0 {
+			return node, nil, consumed
+		}
+		if hook != nil {
+			return hook(data)
+		}
+		return nil, nil, 0
+	}
+}
+
+func inlineMath(p *mdparser.Parser, data []byte, offset int) (int, ast.Node) {
+	data = data[offset:]
+	n, display := mathLength(data)
+	if n == 0 {
+		return 0, nil
+	}
+
+	if display {
+		math := &DisplayMath{}
+		math.Literal = data[2 : n-2]
+		return n, math
+	}
+	math := &ast.Math{}
+	math.Literal = data[1 : n-1]
+	return n, math
+}
+
+// blockMath parses display math making up a whole block, which may span several lines
+//
+//	$$
+//	\int x dx
+//	$$
+func blockMath(data []byte) (ast.Node, int) {
+	indent := len(data) - len(bytes.TrimLeft(data, " "))
+	if indent > 3 || !bytes.HasPrefix(data[indent:], []byte("$$")) {
+		return nil, 0
+	}
+
+	n, display := mathLength(data[indent:])
+	if n == 0 || !display {
+		return nil, 0
+	}
+
+	// Nothing may follow the closing $$ on its line
+	end := indent + n
+	rest := data[end:]
+	if i := bytes.IndexByte(rest, '\n'); i >= 0 {
+		rest = rest[:i+1]
+	}
+	if len(bytes.TrimSpace(rest)) > 0 {
+		return nil, 0
+	}
+
+	math := &ast.MathBlock{}
+	math.Literal = bytes.TrimSpace(data[indent+2 : end-2])
+	return math, end + len(rest)
+}
+
+// mathLength returns the length of the $...$ or $$...$$ math at the start of s including its
+// dollar signs, or 0 if s does not start with math. Inline math cannot span a blank line, and math
+// never runs into a backtick, so a dollar sign in a later code span can not close it.
+func mathLength[T string | []byte](s T) (int, bool) {
+	if len(s) < 3 || s[0] != '$' {
+		return 0, false
+	}
+
+	if s[1] == '$' {
+		for i := 2; i+1 < len(s); i++ {
+			switch {
+			case s[i] == '\\':
+				i++
+			case s[i] == '`':
+				return 0, false
+			case s[i] == '$' && s[i+1] == '$':
+				if i == 2 {
+					return 0, false
+				}
+				return i + 2, true
+			}
+		}
+		return 0, false
+	}
+
+	if isSpace(s[1]) {
+		return 0, false
+	}
+	for i := 1; i < len(s); i++ {
+		switch {
+		case s[i] == '\\':
+			i++
+		case s[i] == '\n' && i+1 < len(s) && s[i+1] == '\n', s[i] == '`':
+			return 0, false
+		case s[i] == '$':
+			if isSpace(s[i-1]) || i+1 < len(s) && s[i+1] >= '0' && s[i+1] <= '9' {
+				continue
+			}
+			return i + 1, false
+		}
+	}
+	return 0, false
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
@@ -0,0 +1,64 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/gomarkdown/markdown/ast"
+	mdparser "github.com/gomarkdown/markdown/parser"
+)
+
+func TestMath(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		want   []string // Math nodes in the document, prefixed by their kind
+	}{
+		{"inline", "Euler $e^{\\pi i}$ in text", []string{"inline e^{\\pi i}"}},
+		{"display in paragraph", "See $$x^2$$ here", []string{"display x^2"}},
+		{"block", "$$\n\\int x dx\n$$\n\nText", []string{"block \\int x dx"}},
+		{"single line block", "$$\\frac{a}{b}$$", []string{"block \\frac{a}{b}"}},
+		{"prices", "Costs $5 and $10 today", nil},
+		{"space after opening", "a $ b$ c", nil},
+		{"escaped", `Costs \$5 or \$x\$`, nil},
+		{"escaped inside math", `$a \$ b$`, []string{`inline a \$ b`}},
+		{"code span", "Run `echo $HOME $PATH`", nil},
+		{"price before code span", "It costs $5, see `a$b`", nil},
+		{"price before code span with dollar", "From $5 to the `$PATH` var", nil},
+		{"math before code span", "Set $x$ with `x=$1`", []string{"inline x"}},
+		{"code block", "```sh\n$ echo $x$\n$$y$$\n```", nil},
+		{"blank line", "$a\n\nb$", nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			p := mdparser.NewWithExtensions(mdparser.CommonExtensions &^ mdparser.MathJax)
+			RegisterMath(p)
+			doc := p.Parse([]byte(test.source))
+
+			var got []string
+			ast.WalkFunc(doc, func(node ast.Node, entering bool) ast.WalkStatus {
+				if !entering {
+					return ast.GoToNext
+				}
+				switch node := node.(type) {
+				case *ast.Math:
+					got = append(got, "inline "+string(node.Literal))
+				case *DisplayMath:
+					got = append(got, "display "+string(node.Literal))
+				case *ast.MathBlock:
+					got = append(got, "block "+string(node.Literal))
+				}
+				return ast.GoToNext
+			})
+
+			if len(got) != len(test.want) {
+				t.Fatalf("got math %q, want %q", got, test.want)
+			}
+			for i := range got {
+				if got[i] != test.want[i] {
+					t.Fatalf("got math %q, want %q", got, test.want)
+				}
+			}
+		})
+	}
+}
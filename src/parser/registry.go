@@ -0,0 +1,307 @@
+package parser
+
+import (
+	"fmt"
+	"html"
+	"path/filepath"
+	"strings"
+
+	"github.com/gomarkdown/markdown/ast"
+)
+
+// Mode controls what a directive handler receives as content, and where its output goes
+type Mode int
+
+const (
+	Rendered Mode = iota // Block content is rendered to HTML, the handler returns HTML
+	Raw                  // Block content is passed as Markdown source, the handler returns HTML
+	Macro                // Block content is passed as Markdown source, the handler returns Markdown that replaces the directive before parsing
+)
+
+// Handler renders a single use of a directive
+type Handler func(call *Call) (string, error)
+
+// Call is a single use of a directive in a page
+type Call struct {
+	Name    string
+	Args    Args
+	Block   bool     // Written as {name}...{/name}
+	Content string   // Content of a block directive, rendered or raw depending on the mode of the handler
+	Line    int      // Line of the directive in the page
+	Col     int      // Column of the directive in the page
+	Node    ast.Node // Placeholder node of the directive in the parsed document, nil for macros
+	Context *RenderContext
+}
+
+type registration struct {
+	mode    Mode
+	handler Handler
+}
+
+// Registry binds directive names to handlers
+type Registry struct {
+	handlers map[string]registration
+}
+
+// NewRegistry returns a registry with the built in directives: sidenote, footnote, chart and toc
+func NewRegistry() *Registry {
+	r := &Registry{handlers: make(map[string]registration)}
+	r.Register("sidenote", Rendered, sidenoteDirective)
+	r.Register("footnote", Macro, footnoteDirective)
+	r.Register("chart", Raw, chartDirective)
+	r.Register("toc", Raw, tocDirective)
+	return r
+}
+
+// Register binds a directive name to a handler, replacing any previous handler for the name
+func (r *Registry) Register(name string, mode Mode, handler Handler) {
+	r.handlers[name] = registration{mode: mode, handler: handler}
+}
+
+// RenderContext is the state of rendering a single page, shared by all directives in it and by the
+// fragments rendered for them. Everything numbered or unique within a page, like sidenotes, footnotes,
+// heading IDs and figures, is kept here rather than in package state, so any number of pages can be
+// rendered at the same time. A RenderContext itself belongs to a single render and is not safe for concurrent use.
+type RenderContext struct {
+	Path     string                               // Path of the Markdown source of the page
+	Doc      ast.Node                             // Parsed document of the page, set once it has been parsed
+	TocDepth int                                  // Default depth of tables of contents
+	Render   func(source string, line int) string // Renders a Markdown fragment starting at line of the page with the page's pipeline
+
+	// RenderInline is like Render, for fragments inside a paragraph. Only inline Markdown is parsed,
+	// so the output never has paragraphs, lists or other block elements.
+	RenderInline func(source string, line int) string
+
+	// ResolvePage returns the URL of the page a wiki link names, by its name, path or title
+	ResolvePage func(target string) (string, error)
+
+	counters     map[string]int
+	ids          map[string]bool
+	dependencies []string
+	errors       []error
+	after        []insertion
+	footnotes    []footnote
+}
+
+// insertion is HTML to insert after a block once the directives of a document are rendered
+type insertion struct {
+	block ast.Node
+	html  string
+}
+
+func NewRenderContext(path string) *RenderContext {
+	return &RenderContext{Path: path, counters: make(map[string]int), ids: make(map[string]bool)}
+}
+
+// Dir returns the directory of the page, which files referenced by the page are resolved against
+func (c *RenderContext) Dir() string {
+	return filepath.Dir(c.Path)
+}
+
+// Next increments and returns the named counter, for numbering things like sidenotes within the page
+func (c *RenderContext) Next(counter string) int {
+	c.counters[counter]++
+	return c.counters[counter]
+}
+
+// UniqueID reserves an element ID within the page, adding a number to it if it is already taken
+func (c *RenderContext) UniqueID(id string) string {
+	unique := id
+	for n := 1; c.ids[unique]; n++ {
+		unique = fmt.Sprintf("%s-%d", id, n)
+	}
+	c.ids[unique] = true
+	return unique
+}
+
+// AddDependency records a file the rendered page depends on, so it can be rendered again when the file changes
+func (c *RenderContext) AddDependency(path string) {
+	c.dependencies = append(c.dependencies, path)
+}
+
+func (c *RenderContext) Dependencies() []string {
+	return c.dependencies
+}
+
+// InsertAfter places HTML right after a block of the document being rendered, once all its directives are.
+// HTML inserted after the same block keeps the order it was inserted in.
+func (c *RenderContext) InsertAfter(block ast.Node, html string) {
+	c.after = append(c.after, insertion{block: block, html: html})
+}
+
+// Errors returns the errors found while rendering the page. Syntax errors have lines relative to the page source.
+func (c *RenderContext) Errors() []error {
+	return c.errors
+}
+
+func (c *RenderContext) errorf(line int, col int, format string, args ...any) {
+	c.errors = append(c.errors, &SyntaxError{Line: line, Col: col, Msg: fmt.Sprintf(format, args...)})
+}
+
+// Expand parses the directives in Markdown source, where line is the line of the page the source starts on.
+// Macros are expanded in place and all other known directives are replaced by placeholders,
+// which Insert renders once the Markdown has been parsed. Unknown directives are reported
+// and left as written.
+func (r *Registry) Expand(ctx *RenderContext, source string, line int) (string, []*Directive) {
+	var directives []*Directive
+	expanded := r.expand(ctx, source, line, &directives)
+	return expanded, directives
+}
+
+func (r *Registry) expand(ctx *RenderContext, source string, line int, directives *[]*Directive) string {
+	segments, errs := ParseDirectives(source)
+	for _, err := range errs {
+		if syntaxErr, ok := err.(*SyntaxError); ok {
+			syntaxErr.Line += line - 1
+		}
+	}
+	ctx.errors = append(ctx.errors, errs...)
+
+	var sb strings.Builder
+	var write func(segments []Segment)
+	write = func(segments []Segment) {
+		for _, segment := range segments {
+			d := segment.Directive
+			if d == nil {
+				sb.WriteString(segment.Text)
+				continue
+			}
+			d.Line += line - 1
+
+			registration, ok := r.handlers[d.Name]
+			switch {
+			case !ok:
+				ctx.errorf(d.Line, d.Col, "unknown directive {%s}", d.Name)
+				sb.WriteString(d.open)
+				write(d.Content)
+				sb.WriteString(d.close)
+			case registration.mode == Macro:
+				call := r.call(ctx, d, nil)
+				call.Content = d.Inner
+				output, err := registration.handler(call)
+				if err != nil {
+					ctx.errorf(d.Line, d.Col, "{%s}: %v", d.Name, err)
+					sb.WriteString(d.Source())
+					continue
+				}
+				// The output of a macro may contain directives itself
+				sb.WriteString(r.expand(ctx, output, d.Line, directives))
+			default:
+				sb.WriteString(placeholder(len(*directives)))
+				*directives = append(*directives, d)
+			}
+		}
+	}
+	write(segments)
+
+	return sb.String()
+}
+
+// Insert renders the directives replaced by Expand into the parsed document, in document order,
+// numbering the footnotes of the document along the way. A handler returning an error is reported
+// and rendered as an error message in the page. The first document is the page, which lists the footnotes
+// of all its fragments.
+func (r *Registry) Insert(ctx *RenderContext, doc ast.Node, directives []*Directive) {
+	if ctx.Doc == nil {
+		ctx.Doc = doc
+	}
+
+	// Fragments are rendered while the directives of the page are, so keep their insertions apart
+	after := ctx.after
+	ctx.after = nil
+	defer func() { ctx.after = after }()
+
+	footnotes := newFootnoteRefs(doc)
+	InsertDirectives(doc, directives, func(d *Directive, node ast.Node) string {
+		// Footnotes before the directive are numbered before any in its content
+		footnotes.numberBefore(ctx, node)
+		registration := r.handlers[d.Name]
+
+		call := r.call(ctx, d, node)
+		if d.Block {
+			call.Content = d.Inner
+			if registration.mode == Rendered && ctx.Render != nil {
+				call.Content = ctx.Render(d.Inner, d.Line)
+			}
+		}
+
+		output, err := registration.handler(call)
+		if err != nil {
+			ctx.errorf(d.Line, d.Col, "{%s}: %v", d.Name, err)
+			return fmt.Sprintf(`<div role="alert" class="alert alert-error"><span>%s error: %s</span></div>`, html.EscapeString(d.Name), html.EscapeString(err.Error()))
+		}
+		return output
+	})
+	footnotes.numberBefore(ctx, nil)
+	listFootnotes(ctx, doc)
+
+	last := make(map[ast.Node]ast.Node)
+	for _, insertion := range ctx.after {
+		node := &ast.HTMLBlock{Leaf: ast.Leaf{Literal: []byte(insertion.html)}}
+		sibling, ok := last[insertion.block]
+		if !ok {
+			sibling = insertion.block
+		}
+		insertAfter(sibling, node)
+		last[insertion.block] = node
+	}
+}
+
+func (r *Registry) call(ctx *RenderContext, d *Directive, node ast.Node) *Call {
+	return &Call{
+		Name:    d.Name,
+		Args:    ParseArgs(d.Args),
+		Block:   d.Block,
+		Line:    d.Line,
+		Col:     d.Col,
+		Node:    node,
+		Context: ctx,
+	}
+}
+
+// Args are the arguments of a directive, such as {chart data="sales.csv" type=bar wide}
+type Args struct {
+	Raw        string            // Arguments as written
+	Named      map[string]string // key=value and key="quoted value" arguments
+	Positional []string          // Other words, or quoted strings
+}
+
+// Get returns a named argument, or the empty string if it was not given
+func (a Args) Get(name string) string {
+	return a.Named[name]
+}
+
+func ParseArgs(raw string) Args {
+	args := Args{Raw: raw, Named: make(map[string]string)}
+
+	rest := strings.TrimSpace(raw)
+	for rest != "" {
+		var key, value string
+		if i := strings.IndexAny(rest, "= \t\n\""); i > 0 && rest[i] == '=' {
+			key, rest = rest[:i], rest[i+1:]
+		}
+
+		if strings.HasPrefix(rest, `"`) {
+			end := strings.IndexByte(rest[1:], '"')
+			if end < 0 {
+				end = len(rest) - 1
+			}
+			value, rest = rest[1:end+1], rest[min(end+2, len(rest)):]
+		} else {
+			end := strings.IndexAny(rest, " \t\n")
+			if end < 0 {
+				end = len(rest)
+			}
+			value, rest = rest[:end], rest[end:]
+		}
+
+		if key != "" {
+			args.Named[key] = value
+		} else {
+			args.Positional = append(args.Positional, value)
+		}
+		rest = strings.TrimSpace(rest)
+	}
+
+	return args
+}
@@ -0,0 +1,92 @@
+package parser
+
+import (
+	"errors"
+	"html"
+	"strings"
+	"testing"
+
+	"github.com/gomarkdown/markdown"
+	mdhtml "github.com/gomarkdown/markdown/html"
+	mdparser "github.com/gomarkdown/markdown/parser"
+)
+
+// renderWith renders a page with the directives of r, rendering fragments with the same pipeline.
+// Headings get their IDs like they do in the site's pipeline.
+func renderWith(r *Registry, source string) (string, *RenderContext) {
+	ctx := NewRenderContext("page.md")
+	ctx.Render = func(source string, line int) string {
+		expanded, directives := r.Expand(ctx, source, line)
+		doc := mdparser.NewWithExtensions(mdparser.CommonExtensions).Parse([]byte(expanded))
+		AssignHeadingIDs(ctx, doc)
+		r.Insert(ctx, doc, directives)
+		return string(markdown.Render(doc, mdhtml.NewRenderer(mdhtml.RendererOptions{})))
+	}
+	return ctx.Render(source, 1), ctx
+}
+
+func TestRegistryUnknownDirective(t *testing.T) {
+	ctx := NewRenderContext("page.md")
+	expanded, directives := NewRegistry().Expand(ctx, "Text\n\nSome {nope a b} here.\n", 10)
+
+	if !strings.Contains(expanded, "Some {nope a b} here.") || len(directives) != 0 {
+		t.Errorf("unknown directive is not left as written: %q", expanded)
+	}
+	errs := ctx.Errors()
+	if len(errs) != 1 || errs[0].Error() != "12:6: unknown directive {nope}" {
+		t.Fatalf("got errors %v", errs)
+	}
+}
+
+func TestRegistryModes(t *testing.T) {
+	r := NewRegistry()
+	// Directives are added without any change to the lexer
+	r.Register("box", Rendered, func(call *Call) (string, error) {
+		return `<div class="box">` + call.Content + `</div>`, nil
+	})
+	r.Register("raw", Raw, func(call *Call) (string, error) {
+		return "<pre>" + html.EscapeString(call.Content) + "</pre>", nil
+	})
+	r.Register("greet", Macro, func(call *Call) (string, error) {
+		return "Hello **" + call.Args.Get("name") + "** {kbd Enter}", nil
+	})
+	r.Register("kbd", Raw, func(call *Call) (string, error) {
+		return "<kbd>" + html.EscapeString(call.Args.Raw) + "</kbd>", nil
+	})
+	r.Register("fail", Raw, func(call *Call) (string, error) {
+		return "", errors.New("no luck")
+	})
+
+	tests := []struct {
+		name   string
+		source string
+		want   string // Part of the rendered page
+	}{
+		{"rendered", "{box}\n**bold**\n{/box}\n", `<div class="box"><p><strong>bold</strong></p>`},
+		{"raw", "{raw}\n**bold** {kbd x}\n{/raw}\n", "<pre>\n**bold** {kbd x}\n</pre>"},
+		{"macro", "{greet name=Ada}\n", "<p>Hello <strong>Ada</strong> <kbd>Enter</kbd></p>"},
+		{"inline", "Press {kbd Ctrl+C} to stop.\n", "<p>Press <kbd>Ctrl+C</kbd> to stop.</p>"},
+		{"nested", "{box}\nOuter\n\n{box}\n{greet name=Bo}\n{/box}\n{/box}\n",
+			"<div class=\"box\"><p>Outer</p>\n\n<div class=\"box\"><p>Hello <strong>Bo</strong> <kbd>Enter</kbd></p>\n</div>\n</div>"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			page, ctx := renderWith(r, test.source)
+			if !strings.Contains(page, test.want) {
+				t.Errorf("page is missing %s:\n%s", test.want, page)
+			}
+			if len(ctx.Errors()) > 0 {
+				t.Errorf("unexpected errors: %v", ctx.Errors())
+			}
+		})
+	}
+
+	page, ctx := renderWith(r, "Text\n\n{fail}\n")
+	if !strings.Contains(page, "fail error: no luck") {
+		t.Errorf("failed directive is not shown as an error:\n%s", page)
+	}
+	if errs := ctx.Errors(); len(errs) != 1 || errs[0].Error() != "3:1: {fail}: no luck" {
+		t.Errorf("got errors %v", errs)
+	}
+}
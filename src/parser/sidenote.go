@@ -3,266 +3,130 @@ package parser
 import (
 	"fmt"
 	"html"
-	"regexp"
 	"strings"
-	"sync/atomic"
-)
-
-func error(msg string) {
-	fmt.Errorf("Error at %s", msg)
-}
 
-type TokenType int
-
-const (
-	leftMeta = iota
-	rightMeta
-	sidenote
-	sidenoteBlock
-	chartBlock
-	literal
-	toc
-	eof
+	"github.com/gomarkdown/markdown/ast"
+	mdhtml "github.com/gomarkdown/markdown/html"
 )
 
-type Token struct {
-	typ TokenType
-	val string
-}
-
-type Lexer struct {
-	start   int
-	current int
-	input   string
-	tokens  []Token
-}
-
-func (l *Lexer) isAtEnd() bool {
-	return l.current > len(l.input)
-}
-
-func (l *Lexer) scanTokens() []Token {
-	for !l.isAtEnd() {
-		l.start = l.current
-		l.scanToken()
-	}
-
-	l.tokens = append(l.tokens, Token{typ: eof})
-	return l.tokens
-}
-
-func (l *Lexer) scanToken() {
-	c := l.advance()
-	switch c {
-	case '{':
-		if l.accept('%') {
-			l.tokens = append(l.tokens, Token{typ: leftMeta, val: l.value()})
+// sidenoteDirective renders {sidenote text}, {sidenote: text} and {sidenote}text{/sidenote}.
+// Inline sidenotes get a numbered marker in the text, block sidenotes are placed without one
+// and may contain paragraphs and lists.
+//
+// Every sidenote is anchored to the block it belongs to: the block containing an inline sidenote,
+// or the block before a block sidenote. The sidenote is placed right after that block, so it
+// follows it in the text on narrow screens, and names the block's ID in data-anchor so the
+// script in the page can align the two in the margin.
+func sidenoteDirective(call *Call) (string, error) {
+	markerClasses := "sidenote-marker"
+	// Sidenotes sit in the text after their block, and only move into the margin on wide screens,
+	// matching the breakpoint of the positioning script in the page
+	sidenoteClasses := "sidenote block my-2 border-l-4 border-primary p-2 text-xs transition-all duration-300 ease-in-out hover:bg-base-300 " +
+		"min-[1001px]:float-right min-[1001px]:clear-right min-[1001px]:ml-[50px] min-[1001px]:mr-[-300px] min-[1001px]:w-[250px] min-[1001px]:-mt-12 min-[1001px]:mb-2"
+
+	id := call.Context.Next("sidenote")
+
+	// Block content is rendered as Markdown already, inline content is only rendered as inline Markdown
+	content := call.Content
+	if !call.Block {
+		content = html.EscapeString(call.Args.Raw)
+		if call.Context.RenderInline != nil {
+			content = call.Context.RenderInline(call.Args.Raw, call.Line)
 		}
-	default:
-		error("Unexpected character")
-	}
-}
-
-func (l *Lexer) advance() byte {
-	return l.input[l.current]
-}
-
-func (l *Lexer) peek() byte {
-	if l.isAtEnd() {
-		return 0
 	}
 
-	return l.input[l.current]
-}
-
-func (l *Lexer) accept(expected byte) bool {
-	if l.isAtEnd() {
-		return false
+	block := sidenoteAnchor(call.Node, call.Block)
+	anchor := ""
+	if id := anchorID(call.Context, block, fmt.Sprintf("sidenote-anchor-%d", id)); id != "" {
+		anchor = fmt.Sprintf(` data-anchor="%s"`, html.EscapeString(id))
 	}
-	if l.input[l.current] != expected {
-		return false
-	}
-
-	l.current++
-	return true
-}
-
-func (l *Lexer) value() string {
-	return l.input[l.start:l.current]
-}
+	sidenote := fmt.Sprintf(`<aside class="%s" id="sidenote-%d"%s>%s</aside>`, sidenoteClasses, id, anchor, strings.TrimSpace(content))
 
-func newLexer(input string) *Lexer {
-	return &Lexer{
-		start:   0,
-		current: 0,
-		input:   input,
-		tokens:  make([]Token, 0),
+	if call.Block {
+		return sidenote, nil
 	}
-}
-
-// Global counter for sidenote IDs
-var sidenoteCounter int64
-
-type CodeBlockRange struct {
-	Start, End int
-}
-
-type SidenoteMatch struct {
-	Start, End   int
-	Content      string
-	IsInline     bool
-	ID           int64
-	OriginalText []byte
-}
-
-func postprocessSidenotes(content []byte) []byte {
-	// Handle {sidenote: abc} and {sidenote} blocks
-	parsedBytes := make([]byte, 0)
-
-	inSidenote := false
-	inCodeblock := false
 
-	sidenoteOpener := "{sidenote"
-
-	for i, b := range content {
-		// Check if we get a sidenote
-		if b == '{' && i+len(sidenoteOpener)-1 < len(content) && string(content[i:i+len(sidenoteOpener)]) == sidenoteOpener {
-			if inSidenote {
-				// Already in a sidenote, just append the character
-				parsedBytes = append(parsedBytes, b)
-				continue
-			}
-			inSidenote = true
-			continue
-		}
-		i += 20
+	marker := fmt.Sprintf(`<span class="%s" data-sidenote-id="%d">%d</span>`, markerClasses, id, id)
+	if block == nil {
+		return marker + sidenote, nil
 	}
+	call.Context.InsertAfter(block, sidenote)
+	return marker, nil
 }
 
-func findCodeBlockRanges(content []byte) []CodeBlockRange {
-	var ranges []CodeBlockRange
-
-	// Find code blocks
-	patterns := []string{
-		`<pre[^>]*><code[^>]*>.*?</code></pre>`, // Standard code blocks
-		`<code[^>]*>.*?</code>`,                 // Inline code blocks
+// sidenoteAnchor returns the block a sidenote at node belongs to, or nil if there is none
+func sidenoteAnchor(node ast.Node, isBlock bool) ast.Node {
+	if node == nil {
+		return nil
 	}
 
-	for _, pattern := range patterns {
-		regex := regexp.MustCompile("(?s)" + pattern)
-		matches := regex.FindAllIndex(content, -1)
-
-		for _, match := range matches {
-			ranges = append(ranges, CodeBlockRange{
-				Start: match[0],
-				End:   match[1],
-			})
+	if isBlock {
+		// Skip HTML blocks, like other block sidenotes before this one
+		sibling := ast.GetPrevNode(node)
+		for isHTMLBlock(sibling) {
+			sibling = ast.GetPrevNode(sibling)
 		}
-	}
-
-	return ranges
-}
-
-// isInCodeBlock checks if a position is within any code block range
-func isInCodeBlock(pos int, codeBlocks []CodeBlockRange) bool {
-	for _, block := range codeBlocks {
-		if pos >= block.Start && pos <= block.End {
-			return true
+		if sibling == nil {
+			sibling = ast.GetNextNode(node)
 		}
+		if sibling == nil || isHTMLBlock(sibling) {
+			return nil
+		}
+		node = sibling
 	}
-	return false
-}
-
-func ProcessSidenotes(content []byte) []byte {
-	// Reset counter
-	atomic.StoreInt64(&sidenoteCounter, 0)
-
-	// Find all code blocks
-	codeBlocks := findCodeBlockRanges(content)
-
-	// Find all sidenotes
-	sidenoteMatches := findAllSidenotes(content, codeBlocks)
-
-	markerClasses := "sidenote-marker"
-	sidenoteClasses := "sidenote float-right clear-right ml-[50px] mr-[-300px] w-[250px] -mt-12 mb-2 border-l-4 border-primary p-2 text-xs transition-all duration-300 ease-in-out hover:bg-base-300"
-
-	// Process sidenotes in reverse order so positions don't shift
-	for i := len(sidenoteMatches) - 1; i >= 0; i-- {
-		match := sidenoteMatches[i]
-
-		var replacement []byte
-		if match.IsInline {
-			marker := fmt.Sprintf(`<span class="%s" data-sidenote-id="%d">%d</span>`, markerClasses, match.ID, match.ID)
-			sidenote := fmt.Sprintf(`<aside class="%s" id="sidenote-%d">%s</aside>`, sidenoteClasses, match.ID, html.EscapeString(match.Content))
 
-			replacement = []byte(marker + sidenote)
-		} else {
-			sidenote := fmt.Sprintf(`<aside class="%s" id="sidenote-%d">%s</aside>`, sidenoteClasses, match.ID, html.EscapeString(match.Content))
-			replacement = []byte(sidenote)
+	// The closest paragraph or heading, or else the outermost block, like a list or a table
+	for n := node; n != nil; n = n.GetParent() {
+		switch n := n.(type) {
+		case *ast.Heading:
+			return n
+		case *ast.Paragraph:
+			// Paragraphs in tight lists have no element of their own
+			if !mdhtml.SkipParagraphTags(n) {
+				return n
+			}
+		}
+		if _, ok := n.GetParent().(*ast.Document); ok {
+			return n
 		}
-
-		// Replace the match in the content
-		content = append(content[:match.Start], append(replacement, content[match.End:]...)...)
 	}
-
-	return content
+	return nil
 }
 
-func findAllSidenotes(content []byte, codeBlocks []CodeBlockRange) []SidenoteMatch {
-	var matches []SidenoteMatch
-
-	// Find inline sidenotes
-	inlineRegex := regexp.MustCompile(`\{sidenote\s+([^}]+)\}`)
-	inlineMatches := inlineRegex.FindAllSubmatchIndex(content, -1)
-
-	for _, match := range inlineMatches {
-		start, end := match[0], match[1]
-		contentStart, contentEnd := match[2], match[3]
-
-		if isInCodeBlock(start, codeBlocks) {
-			continue
+// anchorID returns the ID of the element block is rendered as, giving it the fallback ID if it has none.
+// It returns the empty string for blocks that are not rendered with an ID.
+func anchorID(ctx *RenderContext, block ast.Node, fallback string) string {
+	var attribute **ast.Attribute
+	switch block := block.(type) {
+	case *ast.Heading:
+		if block.HeadingID != "" {
+			return block.HeadingID
 		}
-
-		// Generate unique ID
-		id := atomic.AddInt64(&sidenoteCounter, 1)
-		sidenoteContent := strings.TrimSpace(string(content[contentStart:contentEnd]))
-
-		matches = append(matches, SidenoteMatch{
-			Start:        start,
-			End:          end,
-			Content:      sidenoteContent,
-			IsInline:     true,
-			ID:           id,
-			OriginalText: content[start:end],
-		})
-
-		fmt.Printf("DEBUG: Added inline sidenote %d: %s\n", id, sidenoteContent)
+		attribute = &block.Attribute
+	case *ast.Paragraph:
+		attribute = &block.Attribute
+	case *ast.List:
+		attribute = &block.Attribute
+	case *ast.Table:
+		attribute = &block.Attribute
+	case *ast.BlockQuote:
+		attribute = &block.Attribute
+	case *Callout:
+		attribute = &block.Attribute
+	default:
+		return ""
 	}
 
-	// Find block sidenotes
-	blockRegex := regexp.MustCompile(`(?s)\{sidenote\}(.*?)\{/sidenote\}`)
-	blockMatches := blockRegex.FindAllSubmatchIndex(content, -1)
-
-	for _, match := range blockMatches {
-		start, end := match[0], match[1]
-		contentStart, contentEnd := match[2], match[3]
-
-		if isInCodeBlock(start, codeBlocks) {
-			continue
-		}
-
-		id := atomic.AddInt64(&sidenoteCounter, 1)
-		sidenoteContent := strings.TrimSpace(string(content[contentStart:contentEnd]))
-
-		matches = append(matches, SidenoteMatch{
-			Start:        start,
-			End:          end,
-			Content:      sidenoteContent,
-			IsInline:     false,
-			ID:           id,
-			OriginalText: content[start:end],
-		})
+	if *attribute == nil {
+		*attribute = &ast.Attribute{}
 	}
+	if (*attribute).ID == nil {
+		(*attribute).ID = []byte(ctx.UniqueID(fallback))
+	}
+	return string((*attribute).ID)
+}
 
-	return matches
+func isHTMLBlock(node ast.Node) bool {
+	_, ok := node.(*ast.HTMLBlock)
+	return ok
 }
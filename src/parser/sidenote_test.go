@@ -0,0 +1,45 @@
+package parser
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestSidenoteAnchor(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		want   string // Rendered page, with the classes of sidenotes left out
+	}{
+		{"paragraph", "First {sidenote note} here.\n\nSecond.\n",
+			`<p id="sidenote-anchor-1">First <span class="sidenote-marker" data-sidenote-id="1">1</span> here.</p>` + "\n\n" +
+				`<aside id="sidenote-1" data-anchor="sidenote-anchor-1">note</aside>` + "\n\n<p>Second.</p>\n"},
+		{"heading keeps its ID", "# Title {sidenote x}\n\nText.\n",
+			`<h1 id="title">Title <span class="sidenote-marker" data-sidenote-id="1">1</span></h1>` + "\n\n" +
+				`<aside id="sidenote-1" data-anchor="title">x</aside>` + "\n\n<p>Text.</p>\n"},
+		{"tight list", "- item {sidenote x}\n- two\n\nAfter.\n",
+			"<ul id=\"sidenote-anchor-1\">\n<li>item <span class=\"sidenote-marker\" data-sidenote-id=\"1\">1</span></li>\n<li>two</li>\n</ul>\n\n" +
+				`<aside id="sidenote-1" data-anchor="sidenote-anchor-1">x</aside>` + "\n\n<p>After.</p>\n"},
+		{"block after its paragraph", "Para.\n\n{sidenote}\nBlock\n{/sidenote}\n\nAfter.\n",
+			`<p id="sidenote-anchor-1">Para.</p>` + "\n\n" + `<aside id="sidenote-1" data-anchor="sidenote-anchor-1"><p>Block</p></aside>` + "\n\n<p>After.</p>\n"},
+		{"block first", "{sidenote}\nA\n{/sidenote}\n\nPara.\n",
+			`<aside id="sidenote-1" data-anchor="sidenote-anchor-1"><p>A</p></aside>` + "\n\n" + `<p id="sidenote-anchor-1">Para.</p>` + "\n"},
+		{"nothing to anchor to", "{sidenote}\nA\n{/sidenote}\n", `<aside id="sidenote-1"><p>A</p></aside>` + "\n"},
+		{"sidenotes of one block keep their order", "A {sidenote one} and {sidenote two}.\n",
+			`<p id="sidenote-anchor-1">A <span class="sidenote-marker" data-sidenote-id="1">1</span> and <span class="sidenote-marker" data-sidenote-id="2">2</span>.</p>` + "\n\n" +
+				`<aside id="sidenote-1" data-anchor="sidenote-anchor-1">one</aside>` + "\n\n" + `<aside id="sidenote-2" data-anchor="sidenote-anchor-1">two</aside>` + "\n"},
+	}
+
+	classRegex := regexp.MustCompile(` class="sidenote [^"]*"`)
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			page, ctx := renderWith(NewRegistry(), test.source)
+			if got := classRegex.ReplaceAllString(page, ""); got != test.want {
+				t.Errorf("got\n%s\nwant\n%s", got, test.want)
+			}
+			if len(ctx.Errors()) > 0 {
+				t.Errorf("unexpected errors: %v", ctx.Errors())
+			}
+		})
+	}
+}
@@ -0,0 +1,133 @@
+package parser
+
+import (
+	"fmt"
+	"html"
+	"strconv"
+	"strings"
+
+	"github.com/gomarkdown/markdown/ast"
+
+	"website/src/slug"
+)
+
+// Default number of heading levels included in a table of contents
+const DefaultTocDepth = 3
+
+type TocEntry struct {
+	Level    int
+	ID       string
+	Title    string
+	Children []*TocEntry
+}
+
+// BuildToc collects the headings of a parsed document into a nested table of contents.
+// Depth is counted from the topmost heading level used in the document, so a page
+// starting at ## with depth 2 includes ## and ### headings.
+func BuildToc(doc ast.Node, depth int) []*TocEntry {
+	if depth <= 0 {
+		depth = DefaultTocDepth
+	}
+
+	var headings []*ast.Heading
+	minLevel := 0
+	ast.WalkFunc(doc, func(node ast.Node, entering bool) ast.WalkStatus {
+		if heading, ok := node.(*ast.Heading); ok && entering && !heading.IsTitleblock && heading.HeadingID != "" {
+			headings = append(headings, heading)
+			if minLevel == 0 || heading.Level < minLevel {
+				minLevel = heading.Level
+			}
+		}
+		return ast.GoToNext
+	})
+
+	var roots []*TocEntry
+	var stack []*TocEntry
+	for _, heading := range headings {
+		if heading.Level >= minLevel+depth {
+			continue
+		}
+
+		entry := &TocEntry{
+			Level: heading.Level,
+			ID:    heading.HeadingID,
+			Title: headingText(heading),
+		}
+
+		// Pop until the top of the stack is a parent of this heading
+		for len(stack) > 0 && stack[len(stack)-1].Level >= entry.Level {
+			stack = stack[:len(stack)-1]
+		}
+
+		if len(stack) == 0 {
+			roots = append(roots, entry)
+		} else {
+			parent := stack[len(stack)-1]
+			parent.Children = append(parent.Children, entry)
+		}
+		stack = append(stack, entry)
+	}
+
+	return roots
+}
+
+// RenderToc renders a table of contents as a nested list of anchor links
+func RenderToc(entries []*TocEntry) string {
+	if len(entries) == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	sb.WriteString(`<nav class="toc"><ul class="menu">`)
+	renderTocEntries(&sb, entries)
+	sb.WriteString(`</ul></nav>`)
+	return sb.String()
+}
+
+func renderTocEntries(sb *strings.Builder, entries []*TocEntry) {
+	for _, entry := range entries {
+		fmt.Fprintf(sb, `<li><a href="#%s">%s</a>`, html.EscapeString(entry.ID), html.EscapeString(entry.Title))
+		if len(entry.Children) > 0 {
+			sb.WriteString(`<ul>`)
+			renderTocEntries(sb, entry.Children)
+			sb.WriteString(`</ul>`)
+		}
+		sb.WriteString(`</li>`)
+	}
+}
+
+// tocDirective renders a table of contents of the page in place, {toc depth=2} sets the number of heading levels
+func tocDirective(call *Call) (string, error) {
+	depth := call.Context.TocDepth
+	if d, err := strconv.Atoi(call.Args.Get("depth")); err == nil {
+		depth = d
+	}
+	return RenderToc(BuildToc(call.Context.Doc, depth)), nil
+}
+
+// AssignHeadingIDs gives every heading of a document an ID that is unique within the page, made from
+// its text or taken from an explicit {#id}. Directives in a heading do not become part of its ID.
+func AssignHeadingIDs(ctx *RenderContext, doc ast.Node) {
+	ast.WalkFunc(doc, func(node ast.Node, entering bool) ast.WalkStatus {
+		if heading, ok := node.(*ast.Heading); ok && entering && !heading.IsTitleblock {
+			id := heading.HeadingID
+			if id == "" {
+				id = headingSlug(headingText(heading))
+			}
+			heading.HeadingID = ctx.UniqueID(id)
+		}
+		return ast.GoToNext
+	})
+}
+
+// headingSlug is the ID of a heading with text, which is "section" for headings without letters or numbers
+func headingSlug(text string) string {
+	if id := slug.Make(text); id != "" {
+		return id
+	}
+	return "section"
+}
+
+func headingText(heading *ast.Heading) string {
+	return strings.TrimSpace(nodeText(heading))
+}
@@ -0,0 +1,62 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+
+	mdparser "github.com/gomarkdown/markdown/parser"
+)
+
+// tocTitles lists the titles of entries, with their children in brackets
+func tocTitles(entries []*TocEntry) string {
+	var titles []string
+	for _, entry := range entries {
+		title := entry.Title
+		if len(entry.Children) > 0 {
+			title += " [" + tocTitles(entry.Children) + "]"
+		}
+		titles = append(titles, title)
+	}
+	return strings.Join(titles, ", ")
+}
+
+func TestBuildToc(t *testing.T) {
+	source := "## Intro\n\n### Setup\n\n#### Details\n\n## Usage\n\n### Setup\n"
+	tests := []struct {
+		depth int
+		want  string
+	}{
+		{1, "Intro, Usage"},
+		{2, "Intro [Setup], Usage [Setup]"},
+		{3, "Intro [Setup [Details]], Usage [Setup]"},
+		{0, "Intro [Setup [Details]], Usage [Setup]"}, // DefaultTocDepth
+	}
+
+	for _, test := range tests {
+		ctx := NewRenderContext("page.md")
+		doc := mdparser.NewWithExtensions(mdparser.CommonExtensions).Parse([]byte(source))
+		AssignHeadingIDs(ctx, doc)
+
+		// Depth is counted from the topmost level used, ## here
+		if got := tocTitles(BuildToc(doc, test.depth)); got != test.want {
+			t.Errorf("depth %d: got %s, want %s", test.depth, got, test.want)
+		}
+	}
+}
+
+func TestTocIDs(t *testing.T) {
+	ctx := NewRenderContext("page.md")
+	ctx.UniqueID("setup") // Taken by another element of the page
+	doc := mdparser.NewWithExtensions(mdparser.CommonExtensions).Parse([]byte("# Setup\n\n## Setup\n\n## Other {#custom}\n\n## ???\n"))
+	AssignHeadingIDs(ctx, doc)
+
+	want := `<nav class="toc"><ul class="menu"><li><a href="#setup-1">Setup</a><ul>` +
+		`<li><a href="#setup-2">Setup</a></li><li><a href="#custom">Other</a></li><li><a href="#section">???</a></li>` +
+		`</ul></li></ul></nav>`
+	if got := RenderToc(BuildToc(doc, 0)); got != want {
+		t.Errorf("got %s\nwant %s", got, want)
+	}
+	if got := RenderToc(nil); got != "" {
+		t.Errorf("got %q for a page without headings", got)
+	}
+}
@@ -0,0 +1,106 @@
+package parser
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"html"
+	"strings"
+
+	"github.com/gomarkdown/markdown/ast"
+	mdparser "github.com/gomarkdown/markdown/parser"
+)
+
+// WikiLink is a link to another page of the site by its name, path or title, written as
+//
+//	[[Page Name]], [[folder/page|label]] or [[page#heading]]
+//
+// ResolveWikiLinks turns the links it finds a page for into ordinary links.
+// The ones left are broken, and Err says why.
+type WikiLink struct {
+	ast.Leaf
+	Target   string // Name, path or title of the page, empty for a heading of the same page
+	Fragment string // Heading of the page, without the #
+	Label    string // Text of the link
+	Err      error  // Why no page was found for the link
+}
+
+// RegisterWikiLinks adds [[wiki links]] to a Markdown parser
+func RegisterWikiLinks(p *mdparser.Parser) {
+	link := p.RegisterInline('[', nil)
+	p.RegisterInline('[', func(p *mdparser.Parser, data []byte, offset int) (int, ast.Node) {
+		if n, node := wikiLink(data[offset:]); n > 0 {
+			return n, node
+		}
+		return link(p, data, offset)
+	})
+}
+
+func wikiLink(data []byte) (int, ast.Node) {
+	if !bytes.HasPrefix(data, []byte("[[")) {
+		return 0, nil
+	}
+	end := bytes.Index(data, []byte("]]"))
+	if end < 0 {
+		return 0, nil
+	}
+	inner := string(data[2:end])
+	if strings.TrimSpace(inner) == "" || strings.ContainsAny(inner, "[\n") {
+		return 0, nil
+	}
+
+	target, label, hasLabel := strings.Cut(inner, "|")
+	target, fragment, _ := strings.Cut(target, "#")
+	link := &WikiLink{Target: strings.TrimSpace(target), Fragment: strings.TrimSpace(fragment), Label: strings.TrimSpace(label)}
+	if !hasLabel || link.Label == "" {
+		link.Label = link.Target
+		if link.Fragment != "" && link.Target != "" {
+			link.Label += " › " + link.Fragment
+		} else if link.Fragment != "" {
+			link.Label = link.Fragment
+		}
+	}
+	link.Literal = data[:end+2]
+	return end + 2, link
+}
+
+// Source returns the link as written, without the brackets
+func (l *WikiLink) Source() string {
+	return string(l.Literal[2 : len(l.Literal)-2])
+}
+
+// ResolveWikiLinks finds the pages wiki links refer to with the page's ResolvePage,
+// and replaces the links with links to those pages. Broken links are reported and left in the document.
+func ResolveWikiLinks(ctx *RenderContext, doc ast.Node) {
+	var links []*WikiLink
+	ast.WalkFunc(doc, func(node ast.Node, entering bool) ast.WalkStatus {
+		if link, ok := node.(*WikiLink); ok {
+			links = append(links, link)
+		}
+		return ast.GoToNext
+	})
+
+	for _, link := range links {
+		destination := ""
+		if link.Target != "" {
+			if ctx.ResolvePage == nil {
+				link.Err = errors.New("there are no pages to link to")
+			} else {
+				destination, link.Err = ctx.ResolvePage(link.Target)
+			}
+		}
+		if link.Err != nil {
+			ctx.errors = append(ctx.errors, fmt.Errorf("broken link %s: %w", link.Literal, link.Err))
+			continue
+		}
+
+		if link.Fragment != "" {
+			destination += "#" + headingSlug(link.Fragment)
+		}
+		// The link as written is kept for tools like the link checker to find it in the source
+		attributes := []string{`class="wikilink"`, fmt.Sprintf(`data-wikilink="%s"`, html.EscapeString(link.Source()))}
+		resolved := &ast.Link{Destination: []byte(destination), AdditionalAttributes: attributes}
+		appendChild(resolved, &ast.Text{Leaf: ast.Leaf{Literal: []byte(link.Label)}})
+		replaceNode(link, resolved)
+	}
+}
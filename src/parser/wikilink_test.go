@@ -0,0 +1,60 @@
+package parser
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/gomarkdown/markdown/ast"
+	mdparser "github.com/gomarkdown/markdown/parser"
+)
+
+func TestResolveWikiLinks(t *testing.T) {
+	ctx := NewRenderContext("public/test.md")
+	ctx.ResolvePage = func(target string) (string, error) {
+		if target == "Some Page" {
+			return "/page/folder/some-page", nil
+		}
+		return "", errors.New("no such page")
+	}
+
+	p := mdparser.NewWithExtensions(mdparser.CommonExtensions)
+	RegisterWikiLinks(p)
+	doc := p.Parse([]byte("[[Some Page]], [[Some Page#A Heading|label]], [[#Top]], [[Gone]] and [a](b)"))
+	ResolveWikiLinks(ctx, doc)
+
+	var links, broken []string
+	ast.WalkFunc(doc, func(node ast.Node, entering bool) ast.WalkStatus {
+		switch node := node.(type) {
+		case *ast.Link:
+			if entering {
+				links = append(links, string(node.Destination)+" "+nodeText(node))
+			}
+		case *WikiLink:
+			broken = append(broken, node.Label)
+		}
+		return ast.GoToNext
+	})
+
+	want := []string{"/page/folder/some-page Some Page", "/page/folder/some-page#a-heading label", "#top Top", "b a"}
+	if !reflect.DeepEqual(links, want) {
+		t.Errorf("got links %q, want %q", links, want)
+	}
+	if !reflect.DeepEqual(broken, []string{"Gone"}) || len(ctx.Errors()) != 1 {
+		t.Errorf("got broken links %q and errors %v, want only [[Gone]]", broken, ctx.Errors())
+	}
+}
+
+func TestPageLinks(t *testing.T) {
+	p := mdparser.NewWithExtensions(mdparser.CommonExtensions | mdparser.Footnotes)
+	RegisterWikiLinks(p)
+	doc := p.Parse([]byte("# Title\n\nSee [[Some Page#Heading|this page]] and [the other](/page/other).[^1]\n\n[^1]: A note\n"))
+
+	want := []PageLink{
+		{Target: "Some Page", Fragment: "Heading", Context: "See this page and the other."},
+		{Destination: "/page/other", Context: "See this page and the other."},
+	}
+	if got := PageLinks(doc); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,79 @@
+package src
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/goccy/go-yaml"
+)
+
+// Timestamp is a date, or a date and time, in frontmatter. Timestamps without a zone are in the site's
+// timezone, which is only known once the site config is loaded, so they keep their wall clock time in UTC
+// until In places them in a timezone.
+type Timestamp struct {
+	time.Time
+	Zoned bool // Written with a zone or an offset
+}
+
+// timestampLayouts are the ways timestamps may be written, and whether the layout has a zone
+var timestampLayouts = []struct {
+	layout string
+	zoned  bool
+}{
+	{time.RFC3339, true},
+	{"2006-01-02T15:04:05", false},
+	{"2006-01-02T15:04", false},
+	{"2006-01-02 15:04:05", false},
+	{"2006-01-02 15:04", false},
+	{dateLayout, false},
+}
+
+// ParseTimestamp parses a frontmatter timestamp
+func ParseTimestamp(value string) (Timestamp, error) {
+	value = strings.TrimSpace(value)
+	for _, l := range timestampLayouts {
+		if t, err := time.Parse(l.layout, value); err == nil {
+			return Timestamp{Time: t, Zoned: l.zoned}, nil
+		}
+	}
+	return Timestamp{}, fmt.Errorf("invalid timestamp %q, expected a date like 2006-01-02 or 2006-01-02 15:04", value)
+}
+
+func (t *Timestamp) UnmarshalYAML(b []byte) error {
+	var s string
+	if err := yaml.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	parsed, err := ParseTimestamp(s)
+	if err != nil {
+		return err
+	}
+	*t = parsed
+	return nil
+}
+
+// In returns the time in loc. Timestamps without a zone are taken to be written in loc.
+func (t Timestamp) In(loc *time.Location) time.Time {
+	if t.IsZero() || t.Zoned {
+		return t.Time.In(loc)
+	}
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), loc)
+}
+
+// Schedule returns when a page is published and when it expires, the zero time for those it does not set
+func (fm Frontmatter) Schedule(loc *time.Location) (publish time.Time, expire time.Time) {
+	if !fm.PublishAt.IsZero() {
+		publish = fm.PublishAt.In(loc)
+	}
+	if !fm.ExpireAt.IsZero() {
+		expire = fm.ExpireAt.In(loc)
+	}
+	return publish, expire
+}
+
+// PublishedAt reports whether a page is inside its publishing window at now
+func (fm Frontmatter) PublishedAt(now time.Time, loc *time.Location) bool {
+	publish, expire := fm.Schedule(loc)
+	return (publish.IsZero() || !now.Before(publish)) && (expire.IsZero() || now.Before(expire))
+}
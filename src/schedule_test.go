@@ -0,0 +1,43 @@
+package src
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPublishedAt(t *testing.T) {
+	stockholm, err := time.LoadLocation("Europe/Stockholm")
+	if err != nil {
+		t.Skip("no timezone database:", err)
+	}
+	now := time.Date(2025, 6, 1, 10, 0, 0, 0, time.UTC) // 12:00 in Stockholm
+
+	tests := []struct {
+		publish, expire string
+		published       bool
+	}{
+		{"", "", true},
+		{"2025-06-01 11:59", "", true},
+		{"2025-06-01 12:01", "", false},
+		{"2025-06-01T11:00:00Z", "", false},
+		{"", "2025-06-01", false},
+		{"2025-05-01", "2025-07-01", true},
+	}
+
+	for _, test := range tests {
+		var fm Frontmatter
+		if test.publish != "" {
+			fm.PublishAt, err = ParseTimestamp(test.publish)
+		}
+		if test.expire != "" && err == nil {
+			fm.ExpireAt, err = ParseTimestamp(test.expire)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if published := fm.PublishedAt(now, stockholm); published != test.published {
+			t.Errorf("publish_at %q, expire_at %q: got %v", test.publish, test.expire, published)
+		}
+	}
+}
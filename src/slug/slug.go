@@ -0,0 +1,27 @@
+// Package slug turns text into the IDs used in URLs and heading anchors.
+// It imports nothing from the site, so both the parser and the models can share it.
+package slug
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Make turns text into an ID the way the Markdown parser does for headings, lowercase letters
+// and numbers with dashes between words. Text without letters or numbers has an empty slug.
+func Make(text string) string {
+	var sb strings.Builder
+	dash := false
+	for _, r := range text {
+		if !unicode.IsLetter(r) && !unicode.IsNumber(r) {
+			dash = true
+			continue
+		}
+		if dash && sb.Len() > 0 {
+			sb.WriteByte('-')
+		}
+		dash = false
+		sb.WriteRune(unicode.ToLower(r))
+	}
+	return sb.String()
+}
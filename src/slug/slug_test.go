@@ -0,0 +1,21 @@
+package slug
+
+import "testing"
+
+func TestMake(t *testing.T) {
+	tests := []struct {
+		text, want string
+	}{
+		{"Static Sites", "static-sites"},
+		{"  static-sites!", "static-sites"},
+		{"Go 1.24", "go-1-24"},
+		{"Ünïcode Wörds", "ünïcode-wörds"},
+		{"???", ""},
+	}
+
+	for _, test := range tests {
+		if got := Make(test.text); got != test.want {
+			t.Errorf("%q: got %q, want %q", test.text, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"log"
+	"net/http"
+
+	"website/src/models"
+	"website/templates"
+)
+
+// taxonomy is a way of grouping pages besides their folders, served at /<name> and /<name>/{term}
+type taxonomy struct {
+	name  string
+	title string
+	terms func(models.File) []string
+}
+
+var taxonomies = []taxonomy{
+	{"tags", "Tags", func(file models.File) []string { return file.Tags }},
+	{"categories", "Categories", func(file models.File) []string { return file.Categories }},
+}
+
+// index groups the pages of the site tree by their terms
+func (t taxonomy) index(folder models.Folder) models.Taxonomy {
+	return models.NewTaxonomy(t.name, t.title, folder, t.terms)
+}
+
+// handleTaxonomy lists every term of a taxonomy with the number of pages it has
+func handleTaxonomy(t taxonomy) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		folder, err := siteTree("")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			log.Println("Error reading site tree:", err)
+			return
+		}
+
+		component := templates.Taxonomy(folder, t.index(folder))
+		_ = component.Render(r.Context(), w)
+	}
+}
+
+// handleTaxonomyTerm lists the pages with a term as cards
+func handleTaxonomyTerm(t taxonomy) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		folder, err := siteTree("")
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			log.Println("Error reading site tree:", err)
+			return
+		}
+
+		index := t.index(folder)
+		term, ok := index.Term(r.PathValue("term"))
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+
+		component := templates.TaxonomyTerm(folder, index, term)
+		_ = component.Render(r.Context(), w)
+	}
+}
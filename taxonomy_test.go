@@ -0,0 +1,49 @@
+package main
+
+import (
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestTaxonomyRoutes(t *testing.T) {
+	testSite(t, map[string]string{
+		"a.md":   "---\ntags: [Static Sites, Go]\n---\n# A\n",
+		"b/c.md": "---\ntags: [static-sites]\n---\n# C\n",
+		"d.md":   "# D\n",
+	})
+	if err := os.MkdirAll("static", 0755); err != nil {
+		t.Fatal(err)
+	}
+	router := newRouter()
+
+	// Tags written with other cases or punctuation are listed once, with every page that has them
+	status, body := get(router, "/tags")
+	if status != http.StatusOK || strings.Count(body, `href="/tags/static-sites"`) != 1 || !strings.Contains(body, `href="/tags/go"`) {
+		t.Fatalf("got status %d and tags:\n%s", status, body)
+	}
+	if tag := body[strings.Index(body, `href="/tags/static-sites"`):]; !strings.Contains(tag[:strings.Index(tag, "</a>")], ">2</span>") {
+		t.Errorf("static-sites is not listed with 2 pages:\n%s", tag)
+	}
+
+	_, body = get(router, "/tags/static-sites")
+	body = body[strings.Index(body, "<main"):] // Past the site tree, which lists every page
+	for page, listed := range map[string]bool{"/page/a": true, "/page/b/c": true, "/page/d": false} {
+		if strings.Contains(body, `href="`+page+`"`) != listed {
+			t.Errorf("/tags/static-sites lists %s: %v, want %v:\n%s", page, !listed, listed, body)
+		}
+	}
+	if !strings.Contains(body, "Static Sites") {
+		t.Errorf("term is not named as the first page writes it:\n%s", body)
+	}
+
+	for _, route := range []string{"/tags/nope", "/tags/Static-Sites"} {
+		if status, _ := get(router, route); status != http.StatusNotFound {
+			t.Errorf("got status %d for %s", status, route)
+		}
+	}
+	if _, body := get(router, "/categories"); !strings.Contains(body, "No pages have categories yet.") {
+		t.Errorf("categories without pages are not shown as empty:\n%s", body)
+	}
+}
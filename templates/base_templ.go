@@ -29,7 +29,7 @@ func Base() templ.Component {
 			templ_7745c5c3_Var1 = templ.NopComponent
 		}
 		ctx = templ.ClearChildren(ctx)
-		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 1, "<!doctype html><html lang=\"en\"><head><meta charset=\"UTF-8\"><meta name=\"viewport\" content=\"width=device-width, initial-scale=1.0\"><title>Oscar Korpi</title><link rel=\"preconnect\" href=\"https://fonts.googleapis.com\"><link rel=\"preconnect\" href=\"https://fonts.gstatic.com\" crossorigin><link href=\"https://fonts.googleapis.com/css2?family=Raleway:ital,wght@0,100..900;1,100..900&display=swap\" rel=\"stylesheet\"><link rel=\"stylesheet\" href=\"/static/css/main.css\"><link rel=\"stylesheet\" href=\"/static/css/latex.css\"><link rel=\"stylesheet\" href=\"/static/css/output.css\"><script id=\"MathJax-script\" async src=\"https://cdn.jsdelivr.net/npm/mathjax@3/es5/tex-mml-chtml.js\"></script><script src=\"https://unpkg.com/htmx.org@2.0.4\"></script><script src=\"https://cdn.jsdelivr.net/gh/gnat/surreal@main/surreal.js\"></script><script src=\"https://cdn.plot.ly/plotly-3.0.1.min.js\" charset=\"utf-8\"></script></head><body hx-boost=\"true\" class=\"font-sans h-full w-full grid grid-rows-[auto_1fr]\"><nav class=\"sticky top-0 m-0 p-2 w-full shadow-md z-10 bg-base-100 navbar\"><ul class=\"flex flex-row gap-2 list-none\"><li><a href=\"/\" class=\"btn btn-ghost text-gray-800\">Home</a></li><li><a href=\"/articles\" class=\"btn btn-ghost text-gray-800\">Articles</a></li></ul></nav>")
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 1, "<!doctype html><html lang=\"en\"><head><meta charset=\"UTF-8\"><meta name=\"viewport\" content=\"width=device-width, initial-scale=1.0\"><title>Oscar Korpi</title><link rel=\"preconnect\" href=\"https://fonts.googleapis.com\"><link rel=\"preconnect\" href=\"https://fonts.gstatic.com\" crossorigin><link href=\"https://fonts.googleapis.com/css2?family=Raleway:ital,wght@0,100..900;1,100..900&display=swap\" rel=\"stylesheet\"><link rel=\"stylesheet\" href=\"/static/css/main.css\"><link rel=\"stylesheet\" href=\"/static/css/latex.css\"><link rel=\"stylesheet\" href=\"/static/css/output.css\"><script src=\"https://unpkg.com/htmx.org@2.0.4\"></script><script src=\"https://cdn.jsdelivr.net/gh/gnat/surreal@main/surreal.js\"></script><script src=\"https://cdn.plot.ly/plotly-3.0.1.min.js\" charset=\"utf-8\"></script></head><body hx-boost=\"true\" class=\"font-sans h-full w-full grid grid-rows-[auto_1fr]\"><nav class=\"sticky top-0 m-0 p-2 w-full shadow-md z-10 bg-base-100 navbar\"><ul class=\"flex flex-row gap-2 list-none\"><li><a href=\"/\" class=\"btn btn-ghost text-gray-800\">Home</a></li><li><a href=\"/articles\" class=\"btn btn-ghost text-gray-800\">Articles</a></li><li><a href=\"/tags\" class=\"btn btn-ghost text-gray-800\">Tags</a></li></ul></nav>")
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
@@ -37,7 +37,38 @@ func Base() templ.Component {
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
-		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 2, "<script>\n            // Reprocess MathJax after HTMX loads content\n            document.body.addEventListener('htmx:afterSettle', function(evt) {\n                if (window.MathJax && window.MathJax.typesetPromise) {\n                    MathJax.typesetPromise([evt.detail.elt]).catch((err) => {\n                        console.error('MathJax typeset failed:', err);\n                    });\n                }\n            });\n        </script></body></html>")
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 2, "<script>\n            // Draw {chart} directives from their embedded Plotly config\n            function renderCharts(root) {\n                root.querySelectorAll('figure.chart').forEach(function(chart) {\n                    const plot = chart.querySelector('.chart-plot');\n                    const config = chart.querySelector('.chart-config');\n                    if (!plot || !config || plot.dataset.rendered) {\n                        return;\n                    }\n                    const { data, layout } = JSON.parse(config.textContent);\n                    Plotly.newPlot(plot, data, layout, { responsive: true });\n                    plot.dataset.rendered = 'true';\n                });\n            }\n            document.addEventListener('DOMContentLoaded', function() {\n                renderCharts(document);\n            });\n\n            // Typeset math MathJax is loaded for, and draw charts after HTMX loads content\n            document.body.addEventListener('htmx:afterSettle', function(evt) {\n                if (window.MathJax && window.MathJax.typesetPromise) {\n                    MathJax.typesetPromise([evt.detail.elt]).catch((err) => {\n                        console.error('MathJax typeset failed:', err);\n                    });\n                }\n                renderCharts(evt.detail.elt);\n            });\n        </script></body></html>")
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		return nil
+	})
+}
+
+// MathJax loads MathJax for pages with math that could not be rendered to MathML on the server.
+// It is part of the page rather than the head, so it also runs when htmx swaps the page in.
+func MathJax() templ.Component {
+	return templruntime.GeneratedTemplate(func(templ_7745c5c3_Input templruntime.GeneratedComponentInput) (templ_7745c5c3_Err error) {
+		templ_7745c5c3_W, ctx := templ_7745c5c3_Input.Writer, templ_7745c5c3_Input.Context
+		if templ_7745c5c3_CtxErr := ctx.Err(); templ_7745c5c3_CtxErr != nil {
+			return templ_7745c5c3_CtxErr
+		}
+		templ_7745c5c3_Buffer, templ_7745c5c3_IsBuffer := templruntime.GetBuffer(templ_7745c5c3_W)
+		if !templ_7745c5c3_IsBuffer {
+			defer func() {
+				templ_7745c5c3_BufErr := templruntime.ReleaseBuffer(templ_7745c5c3_Buffer)
+				if templ_7745c5c3_Err == nil {
+					templ_7745c5c3_Err = templ_7745c5c3_BufErr
+				}
+			}()
+		}
+		ctx = templ.InitializeContext(ctx)
+		templ_7745c5c3_Var2 := templ.GetChildren(ctx)
+		if templ_7745c5c3_Var2 == nil {
+			templ_7745c5c3_Var2 = templ.NopComponent
+		}
+		ctx = templ.ClearChildren(ctx)
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 3, "<script>\n        if (!window.MathJax) {\n            // Math is delimited by the Markdown renderer, so MathJax should not look for dollar signs itself\n            window.MathJax = {\n                tex: { inlineMath: [['\\\\(', '\\\\)']], displayMath: [['\\\\[', '\\\\]']] },\n            };\n            const script = document.createElement('script');\n            script.id = 'MathJax-script';\n            script.async = true;\n            script.src = 'https://cdn.jsdelivr.net/npm/mathjax@3/es5/tex-mml-chtml.js';\n            document.head.appendChild(script);\n        }\n    </script>")
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}